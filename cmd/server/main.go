@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -12,6 +13,7 @@ import (
 
 	// Import the packages we built
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/ai"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/analysis"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/db"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/github"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/introspect"
@@ -23,6 +25,14 @@ func main() {
 		log.Println("Warning: No .env file found. Ensure GITHUB_TOKEN is set in system environment.")
 	}
 
+	// "server migrate [up|down N|status]" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	// 1. Initialize Database
 	db.InitializeDatabase()
 
@@ -35,12 +45,45 @@ func main() {
 	// 4. Initialize ElevenLabs TTS Client
 	elevenLabsClient := ai.NewElevenLabsClient()
 
+	// Optional offline OSV snapshot for vulnerability matching
+	var vulnDB *analysis.VulnDatabase
+	if osvPath := os.Getenv("OSV_DB_PATH"); osvPath != "" {
+		loaded, err := analysis.LoadVulnDatabase(osvPath)
+		if err != nil {
+			log.Printf("Warning: %v. Vulnerability matching is disabled.", err)
+		} else {
+			log.Printf("Loaded %d OSV advisories from %s", loaded.Advisories, osvPath)
+			vulnDB = loaded
+		}
+	}
+
+	// How long a cached report is served before it is rebuilt in the background
+	cacheTTL := introspect.DefaultReportCacheTTL
+	if value := os.Getenv("REPORT_CACHE_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Warning: REPORT_CACHE_TTL %q is not a duration like 12h. Using %s.", value, cacheTTL)
+		} else {
+			cacheTTL = parsed
+		}
+	}
+
 	// 5. Create Repository (The Pantry Manager)
 	repo := introspect.NewReportRepository(db.GlobalDatabaseAccessor)
 
+	// Reports and snapshots older than REPORT_RETENTION are purged every hour; unset keeps them
+	if value := os.Getenv("REPORT_RETENTION"); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			log.Printf("Warning: REPORT_RETENTION %q is not a duration like 2160h. Reports are kept forever.", value)
+		} else {
+			introspect.StartRetentionPurge(repo, retention)
+		}
+	}
+
 	// 6. Create Handler (The Chef)
-	// We now pass the repo, github client, gemini client, and elevenlabs client!
-	handler := introspect.NewHandler(repo, ghClient, geminiClient, elevenLabsClient)
+	// We now pass the repo, github client, gemini client, elevenlabs client, OSV database and cache TTL!
+	handler := introspect.NewHandler(repo, ghClient, geminiClient, elevenLabsClient, vulnDB, cacheTTL)
 
 	// 7. Setup Router
 	router := gin.Default()
@@ -65,12 +108,42 @@ func main() {
 	{
 		api.POST("/analyze", handler.AnalyzeRepo)
 		api.GET("/report/:owner/:repo", handler.GetReport)
+		api.GET("/report/:owner/:repo/dependencies", handler.GetDependencies)
+		api.GET("/report/:owner/:repo/sbom", handler.GetSBOM)
+		api.GET("/report/:owner/:repo/history", handler.GetHistory)
+		api.GET("/report/:owner/:repo/contributors", handler.GetContributors)
+		api.GET("/report/:owner/:repo/diff", handler.DiffReports)
+		api.GET("/compare/:owner/:repo", handler.CompareRefs)
 		api.POST("/smart-summary", handler.SmartSummary)
 		api.POST("/file-tree", handler.GetFileTree)
 		api.POST("/chat", handler.ChatWithRepo)
+		api.POST("/chat/sessions", handler.CreateChatSession)
+		api.GET("/chat/sessions/:id", handler.GetChatSession)
+		api.POST("/chat/sessions/:id/messages", handler.PostChatMessage)
 		api.POST("/voice-chat", handler.VoiceChatWithRepo)
 	}
 
+	// Erased identities are logged as hashes keyed with this secret; analyses need it to
+	// recognize them even when the admin endpoints are off
+	hashKey := os.Getenv("ERASURE_HASH_KEY")
+	analysis.SetIdentityHashKey(hashKey)
+
+	// Admin endpoints only exist when ADMIN_TOKEN is set
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		if hashKey == "" {
+			log.Fatal("ERASURE_HASH_KEY must be set when ADMIN_TOKEN is set")
+		}
+
+		admin := api.Group("/admin", introspect.RequireAdminToken(adminToken))
+		admin.POST("/erasures", handler.EraseContributor)
+		admin.GET("/erasures", handler.ListErasures)
+
+		// Listed sessions can be opened by ID, so the listing keeps its public path but needs the token
+		api.GET("/chat/sessions", introspect.RequireAdminToken(adminToken), handler.ListChatSessions)
+	} else {
+		log.Println("Warning: ADMIN_TOKEN is not set. Admin endpoints are disabled.")
+	}
+
 	log.Println("server started on port :8080...")
 	router.Run(":8080")
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/db"
+)
+
+// runMigrate handles "migrate" / "migrate up", "migrate down [N]" and "migrate status"
+func runMigrate(args []string) error {
+	database, err := db.Connect()
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := db.MigrateUp(database)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is already up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("down takes a positive number of steps, got %q", args[1])
+			}
+		}
+		reverted, err := db.MigrateDown(database, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+
+	case "status":
+		current, err := db.SchemaVersion(database)
+		if err != nil {
+			return err
+		}
+		available, err := db.LoadMigrations(database.Dialector.Name())
+		if err != nil {
+			return err
+		}
+		for _, migration := range available {
+			state := "pending"
+			if migration.Version <= current {
+				state = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", migration.Version, migration.Name, state)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down [N] or status)", command)
+	}
+}
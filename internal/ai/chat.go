@@ -2,6 +2,7 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/github"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 )
 
 // ChatMessage represents a single message in the conversation
@@ -22,14 +24,16 @@ type ChatRequest struct {
 	Owner   string        `json:"owner"`
 	Repo    string        `json:"repo"`
 	Files   []string      `json:"files"`   // File paths to discuss
+	Ref     string        `json:"ref"`     // Commit to read the files at; the default branch when empty
 	Message string        `json:"message"` // User's message
 	History []ChatMessage `json:"history"` // Previous conversation history
 }
 
 // ChatResponse represents the response from chat
 type ChatResponse struct {
-	Response string `json:"response"` // AI's response
-	Error    string `json:"error,omitempty"`
+	Response string               `json:"response"` // AI's response
+	Skipped  []models.SkippedFile `json:"skipped"`  // Selected files that could not be fetched
+	Error    string               `json:"error,omitempty"`
 }
 
 // GeminiChatRequest represents the multi-turn chat request for Gemini
@@ -44,9 +48,9 @@ type GeminiChatContent struct {
 }
 
 // Chat handles a conversation about specific files
-func (g *GeminiClient) Chat(ghClient *github.Client, req *ChatRequest) (*ChatResponse, error) {
+func (g *GeminiClient) Chat(ctx context.Context, ghClient *github.Client, req *ChatRequest) (*ChatResponse, error) {
 	// Fetch file contents
-	fileContents, err := ghClient.FetchMultipleFiles(req.Owner, req.Repo, req.Files)
+	batch, err := ghClient.FetchMultipleFilesAt(ctx, req.Owner, req.Repo, req.Ref, req.Files)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch files: %w", err)
 	}
@@ -66,8 +70,13 @@ func (g *GeminiClient) Chat(ghClient *github.Client, req *ChatRequest) (*ChatRes
 
 	contextBuilder.WriteString("Here are the files from the repository:\n\n")
 
-	for path, content := range fileContents {
-		contextBuilder.WriteString(fmt.Sprintf("=== FILE: %s ===\n", path))
+	for _, file := range batch.Fetched() {
+		content := file.Content
+		contextBuilder.WriteString(fmt.Sprintf("=== FILE: %s ===\n", file.Path))
+		if file.Descriptor.IsBinary {
+			contextBuilder.WriteString(describeBinary(file) + "\n\n")
+			continue
+		}
 		// Truncate very long files
 		if len(content) > 15000 {
 			content = content[:15000] + "\n... [truncated for length]"
@@ -118,16 +127,16 @@ func (g *GeminiClient) Chat(ghClient *github.Client, req *ChatRequest) (*ChatRes
 	}
 
 	// Call Gemini API
-	response, err := g.callGeminiChat(contents)
+	response, err := g.callGeminiChat(ctx, contents)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AI response: %w", err)
 	}
 
-	return &ChatResponse{Response: response}, nil
+	return &ChatResponse{Response: response, Skipped: batch.Skipped()}, nil
 }
 
 // callGeminiChat makes a chat request to Gemini API
-func (g *GeminiClient) callGeminiChat(contents []GeminiChatContent) (string, error) {
+func (g *GeminiClient) callGeminiChat(ctx context.Context, contents []GeminiChatContent) (string, error) {
 	url := fmt.Sprintf("%s/%s:generateContent?key=%s", baseURL, geminiFlashModel, g.apiKey)
 
 	reqBody := GeminiChatRequest{
@@ -143,7 +152,7 @@ func (g *GeminiClient) callGeminiChat(contents []GeminiChatContent) (string, err
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -177,9 +186,9 @@ func (g *GeminiClient) callGeminiChat(contents []GeminiChatContent) (string, err
 }
 
 // GenerateVoiceResponse generates a response for voice mode (shorter, more conversational)
-func (g *GeminiClient) GenerateVoiceResponse(ghClient *github.Client, req *ChatRequest) (*ChatResponse, error) {
+func (g *GeminiClient) GenerateVoiceResponse(ctx context.Context, ghClient *github.Client, req *ChatRequest) (*ChatResponse, error) {
 	// Fetch file contents
-	fileContents, err := ghClient.FetchMultipleFiles(req.Owner, req.Repo, req.Files)
+	batch, err := ghClient.FetchMultipleFilesAt(ctx, req.Owner, req.Repo, req.Ref, req.Files)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch files: %w", err)
 	}
@@ -200,8 +209,13 @@ func (g *GeminiClient) GenerateVoiceResponse(ghClient *github.Client, req *ChatR
 
 	contextBuilder.WriteString("Here are the code files we're discussing:\n\n")
 
-	for path, content := range fileContents {
-		contextBuilder.WriteString(fmt.Sprintf("=== FILE: %s ===\n", path))
+	for _, file := range batch.Fetched() {
+		content := file.Content
+		contextBuilder.WriteString(fmt.Sprintf("=== FILE: %s ===\n", file.Path))
+		if file.Descriptor.IsBinary {
+			contextBuilder.WriteString(describeBinary(file) + "\n\n")
+			continue
+		}
 		// More aggressive truncation for voice mode
 		if len(content) > 8000 {
 			content = content[:8000] + "\n... [truncated]"
@@ -246,10 +260,15 @@ func (g *GeminiClient) GenerateVoiceResponse(ghClient *github.Client, req *ChatR
 	}
 
 	// Call Gemini API
-	response, err := g.callGeminiChat(contents)
+	response, err := g.callGeminiChat(ctx, contents)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AI response: %w", err)
 	}
 
-	return &ChatResponse{Response: response}, nil
+	return &ChatResponse{Response: response, Skipped: batch.Skipped()}, nil
+}
+
+// describeBinary stands in for a binary file's bytes so the model knows it exists without reading garbage
+func describeBinary(file github.FileResult) string {
+	return fmt.Sprintf("[binary file: %s, %d bytes - contents not shown]", file.Descriptor.MIMEType, file.Descriptor.Size)
 }
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -45,12 +46,12 @@ func NewElevenLabsClient() *ElevenLabsClient {
 }
 
 // TextToSpeech converts text to audio and returns the audio bytes
-func (e *ElevenLabsClient) TextToSpeech(text string) ([]byte, error) {
-	return e.TextToSpeechWithVoice(text, defaultVoiceID)
+func (e *ElevenLabsClient) TextToSpeech(ctx context.Context, text string) ([]byte, error) {
+	return e.TextToSpeechWithVoice(ctx, text, defaultVoiceID)
 }
 
 // TextToSpeechWithVoice converts text to audio with a specific voice
-func (e *ElevenLabsClient) TextToSpeechWithVoice(text, voiceID string) ([]byte, error) {
+func (e *ElevenLabsClient) TextToSpeechWithVoice(ctx context.Context, text, voiceID string) ([]byte, error) {
 	if e.apiKey == "" {
 		return nil, fmt.Errorf("ELEVENLABS_API_KEY not set")
 	}
@@ -73,7 +74,7 @@ func (e *ElevenLabsClient) TextToSpeechWithVoice(text, voiceID string) ([]byte,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -102,7 +103,7 @@ func (e *ElevenLabsClient) TextToSpeechWithVoice(text, voiceID string) ([]byte,
 }
 
 // StreamTextToSpeech returns the audio as a stream (for large responses)
-func (e *ElevenLabsClient) StreamTextToSpeech(text, voiceID string) (io.ReadCloser, error) {
+func (e *ElevenLabsClient) StreamTextToSpeech(ctx context.Context, text, voiceID string) (io.ReadCloser, error) {
 	if e.apiKey == "" {
 		return nil, fmt.Errorf("ELEVENLABS_API_KEY not set")
 	}
@@ -123,7 +124,7 @@ func (e *ElevenLabsClient) StreamTextToSpeech(text, voiceID string) (io.ReadClos
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,13 @@ const (
 	baseURL          = "https://generativelanguage.googleapis.com/v1beta/models"
 )
 
+// SmartSummaryModel and SmartSummaryPromptVersion identify what wrote a stored Smart Summary.
+// Bump the prompt version whenever either stage's prompt changes, so stored summaries are redone.
+const (
+	SmartSummaryModel         = geminiProModel
+	SmartSummaryPromptVersion = "1"
+)
+
 type GeminiClient struct {
 	apiKey     string
 	httpClient *http.Client
@@ -71,7 +79,7 @@ func NewGeminiClient() *GeminiClient {
 	}
 }
 
-func (g *GeminiClient) callGemini(model, prompt string, jsonOutput bool) (string, error) {
+func (g *GeminiClient) callGemini(ctx context.Context, model, prompt string, jsonOutput bool) (string, error) {
 	url := fmt.Sprintf("%s/%s:generateContent?key=%s", baseURL, model, g.apiKey)
 
 	config := GenerationConfig{
@@ -99,7 +107,7 @@ func (g *GeminiClient) callGemini(model, prompt string, jsonOutput bool) (string
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -133,7 +141,7 @@ func (g *GeminiClient) callGemini(model, prompt string, jsonOutput bool) (string
 }
 
 // Stage 1: Analyze file tree and identify critical files
-func (g *GeminiClient) IdentifyCriticalFiles(treeString string) ([]string, error) {
+func (g *GeminiClient) IdentifyCriticalFiles(ctx context.Context, treeString string) ([]string, error) {
 	prompt := fmt.Sprintf(`You are a Senior Software Architect. Analyze this file structure of a repository and identify the top 7 most critical files that reveal the core logic of this project.
 
 Focus on:
@@ -159,7 +167,7 @@ Return ONLY a valid JSON object with this exact structure:
 
 Important: Return exactly 7 files. If there are fewer important files, include the most relevant ones available.`, treeString)
 
-	response, err := g.callGemini(geminiFlashModel, prompt, true)
+	response, err := g.callGemini(ctx, geminiFlashModel, prompt, true)
 	if err != nil {
 		return nil, fmt.Errorf("Stage 1 failed: %w", err)
 	}
@@ -179,11 +187,16 @@ Important: Return exactly 7 files. If there are fewer important files, include t
 }
 
 // Stage 2: Deep analysis of critical files
-func (g *GeminiClient) GenerateDeepSummary(files map[string]string) (*models.SmartSummary, error) {
-	// Build the file contents string
+func (g *GeminiClient) GenerateDeepSummary(ctx context.Context, files []github.FileResult) (*models.SmartSummary, error) {
+	// Build the file contents string, in the order Stage 1 ranked the files
 	var filesContent strings.Builder
-	for path, content := range files {
-		filesContent.WriteString(fmt.Sprintf("\n--- FILE: %s ---\n", path))
+	for _, file := range files {
+		content := file.Content
+		filesContent.WriteString(fmt.Sprintf("\n--- FILE: %s ---\n", file.Path))
+		if file.Descriptor.IsBinary {
+			filesContent.WriteString(describeBinary(file) + "\n")
+			continue
+		}
 		// Truncate very long files to avoid token limits
 		if len(content) > 10000 {
 			content = content[:10000] + "\n... [truncated]"
@@ -232,7 +245,7 @@ Example format:
 
 Return ONLY the JSON object, no additional text.`, filesContent.String())
 
-	response, err := g.callGemini(geminiProModel, prompt, true)
+	response, err := g.callGemini(ctx, geminiProModel, prompt, true)
 	if err != nil {
 		return nil, fmt.Errorf("Stage 2 failed: %w", err)
 	}
@@ -262,21 +275,42 @@ Return ONLY the JSON object, no additional text.`, filesContent.String())
 	return &summary, nil
 }
 
-// GenerateSmartSummary is the main autonomous agent function
-func (g *GeminiClient) GenerateSmartSummary(ghClient *github.Client, owner, repo string) (*models.SmartSummary, string, error) {
+// Smart Summary stage deadlines, on top of whatever deadline the caller's context has
+const (
+	scanStageTimeout = 60 * time.Second  // Tree fetch + Stage 1 model call
+	readStageTimeout = 120 * time.Second // File downloads + Stage 2 model call
+)
+
+// GenerateSmartSummary is the main autonomous agent function. ref is the commit to summarize
+// (the main or master branch when empty). includeSubmodules lets files inside GitHub-hosted
+// submodules be picked as critical files.
+func (g *GeminiClient) GenerateSmartSummary(ctx context.Context, ghClient *github.Client, owner, repo, ref string, includeSubmodules bool) (*models.SmartSummary, string, error) {
 	stage := "scanning_structure"
 
 	// Stage 1: Fetch and analyze file tree
 	fmt.Printf("[Stage 1] Fetching file tree for %s/%s...\n", owner, repo)
-	tree, err := ghClient.FetchRepoTree(owner, repo)
+	stageCtx, cancel := context.WithTimeout(ctx, scanStageTimeout)
+	defer cancel()
+	var tree *github.TreeResponse
+	var err error
+	if ref != "" {
+		tree, err = ghClient.FetchTreeAt(stageCtx, owner, repo, ref)
+	} else {
+		tree, err = ghClient.FetchRepoTree(stageCtx, owner, repo)
+	}
 	if err != nil {
 		return nil, stage, fmt.Errorf("failed to fetch repo tree: %w", err)
 	}
+	if includeSubmodules {
+		if err := ghClient.IncludeSubmodules(stageCtx, owner, repo, ref, tree); err != nil {
+			fmt.Printf("[Stage 1] Could not expand submodules: %v\n", err)
+		}
+	}
 
 	treeString := tree.GetTreeAsString()
 	fmt.Printf("[Stage 1] Analyzing %d files to identify critical ones...\n", len(tree.Tree))
 
-	criticalFiles, err := g.IdentifyCriticalFiles(treeString)
+	criticalFiles, err := g.IdentifyCriticalFiles(stageCtx, treeString)
 	if err != nil {
 		return nil, stage, fmt.Errorf("failed to identify critical files: %w", err)
 	}
@@ -286,16 +320,26 @@ func (g *GeminiClient) GenerateSmartSummary(ghClient *github.Client, owner, repo
 	stage = "reading_files"
 	fmt.Printf("[Stage 2] Fetching content of %d critical files...\n", len(criticalFiles))
 
-	fileContents, err := ghClient.FetchMultipleFiles(owner, repo, criticalFiles)
+	readCtx, cancelRead := context.WithTimeout(ctx, readStageTimeout)
+	defer cancelRead()
+	batch, err := ghClient.FetchTreeFiles(readCtx, owner, repo, ref, tree, criticalFiles)
 	if err != nil {
 		return nil, stage, fmt.Errorf("failed to fetch file contents: %w", err)
 	}
-	fmt.Printf("[Stage 2] Fetched %d files, generating deep summary...\n", len(fileContents))
+	fetched := batch.Fetched()
+	fmt.Printf("[Stage 2] Fetched %d files (%d skipped), generating deep summary...\n", len(fetched), len(criticalFiles)-len(fetched))
 
-	summary, err := g.GenerateDeepSummary(fileContents)
+	summary, err := g.GenerateDeepSummary(readCtx, fetched)
 	if err != nil {
 		return nil, stage, fmt.Errorf("failed to generate summary: %w", err)
 	}
+	summary.SkippedFiles = batch.Skipped()
+	summary.CriticalFiles = criticalFiles
+	summary.Model = SmartSummaryModel
+	summary.PromptVersion = SmartSummaryPromptVersion
+	summary.CommitSHA = ref
+	summary.TreeSHA = tree.SHA
+	summary.GeneratedAt = time.Now()
 
 	fmt.Printf("[Complete] Generated smart summary for %s/%s\n", owner, repo)
 	return summary, "complete", nil
@@ -0,0 +1,802 @@
+package analysis
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// Like the manifest parsers, every CI parser is best-effort: a config we can't fully read
+// still shows up as a pipeline of its provider, just with fewer details.
+
+// ciProvider names the CI system a tree path configures, or "" when it isn't a CI config
+func ciProvider(filePath string) string {
+	if isVendored(filePath) {
+		return ""
+	}
+	base := path.Base(filePath)
+	ext := path.Ext(filePath)
+	isYAML := ext == ".yml" || ext == ".yaml"
+
+	switch {
+	case path.Dir(filePath) == ".github/workflows" && isYAML:
+		return "github-actions"
+	case filePath == ".gitlab-ci.yml":
+		return "gitlab-ci"
+	case base == "Jenkinsfile" || strings.HasSuffix(base, ".jenkinsfile"):
+		return "jenkins"
+	case filePath == ".circleci/config.yml":
+		return "circleci"
+	case filePath == ".travis.yml":
+		return "travis-ci"
+	case filePath == "azure-pipelines.yml" || filePath == "azure-pipelines.yaml" || strings.HasPrefix(filePath, ".azure-pipelines/") && isYAML:
+		return "azure-pipelines"
+	case filePath == "bitbucket-pipelines.yml":
+		return "bitbucket-pipelines"
+	case filePath == ".drone.yml":
+		return "drone"
+	case path.Dir(filePath) == ".buildkite" && isYAML:
+		return "buildkite"
+	}
+	return ""
+}
+
+// ciParsers turn one config into a pipeline; providers without a parser are only detected
+var ciParsers = map[string]func(pipeline *models.CIPipeline, content string){
+	"github-actions":      parseGitHubWorkflow,
+	"gitlab-ci":           parseGitLabCI,
+	"jenkins":             parseJenkinsfile,
+	"circleci":            parseCircleCI,
+	"travis-ci":           parseTravisCI,
+	"azure-pipelines":     parseAzurePipelines,
+	"bitbucket-pipelines": parseBitbucketPipelines,
+}
+
+// IsCIConfig reports whether a tree path is a CI/CD configuration we recognize
+func IsCIConfig(filePath string) bool {
+	return ciProvider(filePath) != ""
+}
+
+// FindCIConfigs picks the CI configuration paths out of a flat list of tree paths
+func FindCIConfigs(paths []string) []string {
+	var configs []string
+	for _, p := range paths {
+		if IsCIConfig(p) {
+			configs = append(configs, p)
+		}
+	}
+	sort.Strings(configs)
+	return configs
+}
+
+// BuildCIReport parses every CI configuration into pipelines and totals up the
+// secrets and unpinned third-party actions they reference across the whole repository
+func BuildCIReport(files map[string]string) models.CIReport {
+	report := models.CIReport{
+		Providers: []string{},
+		Pipelines: []models.CIPipeline{},
+		Secrets:   []string{},
+	}
+
+	providers := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for _, p := range sortedKeys(files) {
+		provider := ciProvider(p)
+		if provider == "" {
+			continue
+		}
+		providers[provider] = true
+
+		pipeline := models.CIPipeline{
+			Path:     p,
+			Provider: provider,
+			Triggers: []string{},
+			Jobs:     []models.CIJob{},
+			Actions:  []models.CIAction{},
+			Secrets:  []string{},
+		}
+		if parse, ok := ciParsers[provider]; ok {
+			parse(&pipeline, files[p])
+		}
+
+		pipeline.Triggers = uniqueSorted(pipeline.Triggers)
+		pipeline.Secrets = uniqueSorted(pipeline.Secrets)
+		for _, secret := range pipeline.Secrets {
+			secrets[secret] = true
+		}
+		for _, action := range pipeline.Actions {
+			if !action.FirstParty && action.Pinning != "sha" {
+				report.UnpinnedActions++
+			}
+		}
+		report.Pipelines = append(report.Pipelines, pipeline)
+	}
+
+	report.Providers = sortedKeys(providers)
+	report.Secrets = sortedKeys(secrets)
+	return report
+}
+
+// --- Pinning ---
+
+var (
+	commitSHAPattern  = regexp.MustCompile(`^([0-9a-f]{40}|sha256:[0-9a-f]{64})$`)
+	versionRefPattern = regexp.MustCompile(`^v?\d+(\.\d+)*([-.+][0-9A-Za-z.]+)?$`)
+)
+
+// classifyPin says how firmly a reference is pinned: "sha" is immutable, "tag" can be
+// moved by the publisher, "branch" moves on every push and "unpinned" takes whatever is latest
+func classifyPin(ref string) string {
+	switch {
+	case ref == "":
+		return "unpinned"
+	case commitSHAPattern.MatchString(ref):
+		return "sha"
+	case versionRefPattern.MatchString(ref):
+		return "tag"
+	}
+	return "branch"
+}
+
+// newCIAction splits "owner/name@ref" style references
+func newCIAction(uses, separator string) models.CIAction {
+	name, ref := uses, ""
+	if i := strings.LastIndex(uses, separator); i > 0 {
+		name, ref = uses[:i], uses[i+len(separator):]
+	}
+	return models.CIAction{Uses: name, Ref: ref, Pinning: classifyPin(ref)}
+}
+
+// dockerAction handles "docker://image:tag" and "image@sha256:..." references
+func dockerAction(image string) models.CIAction {
+	image = strings.TrimPrefix(image, "docker://")
+	if i := strings.Index(image, "@"); i > 0 {
+		return models.CIAction{Uses: image[:i], Ref: image[i+1:], Pinning: classifyPin(image[i+1:])}
+	}
+	// The tag separator is the last ":" after the last "/" (registries can have ports)
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return models.CIAction{Uses: image[:i], Ref: image[i+1:], Pinning: classifyPin(image[i+1:])}
+	}
+	return models.CIAction{Uses: image, Pinning: "unpinned"}
+}
+
+// --- GitHub Actions ---
+
+var githubSecretPattern = regexp.MustCompile(`secrets\.([A-Za-z_][A-Za-z0-9_]*)|secrets\[\s*['"]([^'"]+)['"]\s*\]`)
+
+func parseGitHubWorkflow(pipeline *models.CIPipeline, content string) {
+	for _, match := range githubSecretPattern.FindAllStringSubmatch(content, -1) {
+		pipeline.Secrets = append(pipeline.Secrets, firstNonEmpty(match[1], match[2]))
+	}
+
+	var workflow map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return
+	}
+	pipeline.Name = yamlString(workflow["name"])
+
+	// YAML 1.1 parsers turn a bare `on` into true, so accept both spellings
+	on, ok := workflow["on"]
+	if !ok {
+		on = workflow["true"]
+	}
+	pipeline.Triggers = yamlStrings(on)
+
+	jobs := yamlMap(workflow["jobs"])
+	for _, id := range sortedKeys(jobs) {
+		spec := yamlMap(jobs[id])
+		job := models.CIJob{
+			Name:    firstNonEmpty(yamlString(spec["name"]), id),
+			Runners: githubRunners(spec["runs-on"]),
+		}
+
+		// A job can call a reusable workflow instead of running steps itself
+		if uses := yamlString(spec["uses"]); uses != "" && !strings.HasPrefix(uses, "./") {
+			action := newCIAction(uses, "@")
+			action.FirstParty = isFirstPartyAction(uses)
+			pipeline.Actions = append(pipeline.Actions, action)
+		}
+
+		if strategy := yamlMap(spec["strategy"]); strategy != nil {
+			job.MatrixSize = githubMatrixSize(strategy["matrix"])
+		}
+
+		steps := yamlList(spec["steps"])
+		job.Steps = len(steps)
+		for _, step := range steps {
+			uses := yamlString(yamlMap(step)["uses"])
+			switch {
+			case uses == "" || strings.HasPrefix(uses, "./"):
+				continue // local actions are reviewed with the repository itself
+			case strings.HasPrefix(uses, "docker://"):
+				pipeline.Actions = append(pipeline.Actions, dockerAction(uses))
+			default:
+				action := newCIAction(uses, "@")
+				action.FirstParty = isFirstPartyAction(uses)
+				pipeline.Actions = append(pipeline.Actions, action)
+			}
+		}
+
+		pipeline.Jobs = append(pipeline.Jobs, job)
+	}
+}
+
+// isFirstPartyAction reports whether an action is published by GitHub itself
+func isFirstPartyAction(uses string) bool {
+	return strings.HasPrefix(uses, "actions/") || strings.HasPrefix(uses, "github/")
+}
+
+// githubRunners reads runs-on as a label, a label list or a {group, labels} object
+func githubRunners(value interface{}) []string {
+	if m := yamlMap(value); m != nil {
+		runners := yamlStrings(m["labels"])
+		if group := yamlString(m["group"]); group != "" {
+			runners = append([]string{"group:" + group}, runners...)
+		}
+		return runners
+	}
+	return yamlStrings(value)
+}
+
+// githubMatrixSize counts the jobs a strategy.matrix expands to. A matrix built from an
+// expression (e.g. fromJSON) is only known at run time and counts as 0.
+func githubMatrixSize(value interface{}) int {
+	matrix := yamlMap(value)
+	if matrix == nil {
+		return 0
+	}
+
+	dims := make(map[string][]string)
+	size := 1
+	for key, values := range matrix {
+		if key == "include" || key == "exclude" {
+			continue
+		}
+		list, ok := values.([]interface{})
+		if !ok {
+			return 0
+		}
+		for _, v := range list {
+			dims[key] = append(dims[key], fmt.Sprint(v))
+		}
+		size *= len(list)
+	}
+	if len(dims) == 0 {
+		size = 0
+	}
+
+	size -= len(yamlList(matrix["exclude"]))
+	if size < 0 {
+		size = 0
+	}
+
+	// An include either extends existing combinations or, when it would overwrite
+	// one of their original values, adds a combination of its own
+	for _, include := range yamlList(matrix["include"]) {
+		if !extendsCombination(yamlMap(include), dims) {
+			size++
+		}
+	}
+	return size
+}
+
+func extendsCombination(include map[string]interface{}, dims map[string][]string) bool {
+	if len(dims) == 0 {
+		return false
+	}
+	for key, value := range include {
+		if values, ok := dims[key]; ok && !containsString(values, fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// --- GitLab CI ---
+
+// gitlabReserved are top-level keys of .gitlab-ci.yml that are not jobs
+var gitlabReserved = map[string]bool{
+	"stages": true, "variables": true, "default": true, "include": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true, "cache": true, "types": true,
+}
+
+// gitlabOnKeywords maps only:/except: keywords to the trigger names we report
+var gitlabOnKeywords = map[string]string{
+	"branches": "push", "pushes": "push", "tags": "tag", "merge_requests": "merge_request",
+	"schedules": "schedule", "api": "api", "web": "manual", "triggers": "trigger",
+	"pipelines": "pipeline", "external_pull_requests": "pull_request",
+}
+
+var gitlabSourcePattern = regexp.MustCompile(`\$CI_PIPELINE_SOURCE\s*==\s*["']?(\w+)`)
+
+func parseGitLabCI(pipeline *models.CIPipeline, content string) {
+	for _, match := range gitlabSourcePattern.FindAllStringSubmatch(content, -1) {
+		pipeline.Triggers = append(pipeline.Triggers, match[1])
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return
+	}
+
+	for _, include := range yamlList(config["include"]) {
+		if action, ok := gitlabInclude(include); ok {
+			pipeline.Actions = append(pipeline.Actions, action)
+		}
+	}
+	if include, ok := config["include"].(string); ok {
+		if action, ok := gitlabInclude(include); ok {
+			pipeline.Actions = append(pipeline.Actions, action)
+		}
+	}
+
+	defaults := yamlMap(config["default"])
+	defaultImage := firstNonEmpty(gitlabImage(defaults["image"]), gitlabImage(config["image"]))
+
+	for _, id := range sortedKeys(config) {
+		spec := yamlMap(config[id])
+		if gitlabReserved[id] || strings.HasPrefix(id, ".") || spec == nil {
+			continue // hidden keys are templates for extends:
+		}
+
+		job := models.CIJob{Name: id, Runners: yamlStrings(spec["tags"])}
+		if len(job.Runners) == 0 {
+			if image := firstNonEmpty(gitlabImage(spec["image"]), defaultImage); image != "" {
+				job.Runners = []string{image}
+			}
+		}
+		job.Steps = len(yamlList(spec["script"]))
+		job.MatrixSize = gitlabParallel(spec["parallel"])
+
+		for _, keyword := range yamlStrings(spec["only"]) {
+			if trigger, ok := gitlabOnKeywords[keyword]; ok {
+				pipeline.Triggers = append(pipeline.Triggers, trigger)
+			}
+		}
+		// HashiCorp Vault / cloud secret manager bindings
+		pipeline.Secrets = append(pipeline.Secrets, sortedKeys(yamlMap(spec["secrets"]))...)
+
+		pipeline.Jobs = append(pipeline.Jobs, job)
+	}
+
+	if len(pipeline.Triggers) == 0 {
+		pipeline.Triggers = []string{"push"}
+	}
+}
+
+// gitlabInclude turns a remote include into an action; local and template includes are skipped
+func gitlabInclude(value interface{}) (models.CIAction, bool) {
+	if s, ok := value.(string); ok {
+		if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+			return models.CIAction{Uses: s, Pinning: "unpinned"}, true
+		}
+		return models.CIAction{}, false
+	}
+
+	include := yamlMap(value)
+	switch {
+	case include["component"] != nil:
+		return newCIAction(yamlString(include["component"]), "@"), true
+	case include["project"] != nil:
+		ref := yamlString(include["ref"])
+		return models.CIAction{Uses: yamlString(include["project"]), Ref: ref, Pinning: classifyPin(ref)}, true
+	case include["remote"] != nil:
+		return models.CIAction{Uses: yamlString(include["remote"]), Pinning: "unpinned"}, true
+	case include["template"] != nil:
+		return models.CIAction{Uses: yamlString(include["template"]), Pinning: "unpinned", FirstParty: true}, true
+	}
+	return models.CIAction{}, false
+}
+
+func gitlabImage(value interface{}) string {
+	if m := yamlMap(value); m != nil {
+		return yamlString(m["name"])
+	}
+	return yamlString(value)
+}
+
+// gitlabParallel reads `parallel: 5` or `parallel: matrix: [{A: [..], B: [..]}, ...]`
+func gitlabParallel(value interface{}) int {
+	if n, ok := yamlInt(value); ok {
+		return n
+	}
+	total := 0
+	for _, entry := range yamlList(yamlMap(value)["matrix"]) {
+		combinations := 1
+		for _, values := range yamlMap(entry) {
+			if list := yamlList(values); len(list) > 0 {
+				combinations *= len(list)
+			}
+		}
+		total += combinations
+	}
+	return total
+}
+
+// --- Jenkins ---
+
+var (
+	jenkinsStagePattern   = regexp.MustCompile(`stage\s*\(\s*['"]([^'"]+)['"]`)
+	jenkinsAgentPattern   = regexp.MustCompile(`agent\s*\{\s*(?:label\s+['"]([^'"]+)['"]|docker\s+['"]([^'"]+)['"]|docker\s*\{[^}]*?image\s+['"]([^'"]+)['"])|agent\s+(any|none)|node\s*\(\s*['"]([^'"]+)['"]`)
+	jenkinsTriggers       = map[string]*regexp.Regexp{"schedule": regexp.MustCompile(`\bcron\s*\(`), "poll": regexp.MustCompile(`\bpollSCM\s*\(`), "upstream": regexp.MustCompile(`\bupstream\s*\(`), "push": regexp.MustCompile(`\bgithubPush\s*\(`)}
+	jenkinsAxisPattern    = regexp.MustCompile(`axis\s*\{\s*name\s+['"][^'"]+['"]\s*values\s+([^\n}]+)`)
+	jenkinsQuotedPattern  = regexp.MustCompile(`['"][^'"]*['"]`)
+	jenkinsLibraryPattern = regexp.MustCompile(`@Library\s*\(\s*\[?\s*['"]([^'"]+)['"]|\blibrary\s*\(?\s*['"]([^'"]+)['"]`)
+	jenkinsCredsPattern   = regexp.MustCompile(`credentials\s*\(\s*['"]([^'"]+)['"]\s*\)|credentialsId\s*:\s*['"]([^'"]+)['"]`)
+)
+
+func parseJenkinsfile(pipeline *models.CIPipeline, content string) {
+	var runners []string
+	for _, match := range jenkinsAgentPattern.FindAllStringSubmatch(content, -1) {
+		if runner := firstNonEmpty(match[1:]...); runner != "none" {
+			runners = append(runners, runner)
+		}
+	}
+	runners = uniqueSorted(runners)
+
+	for trigger, pattern := range jenkinsTriggers {
+		if pattern.MatchString(content) {
+			pipeline.Triggers = append(pipeline.Triggers, trigger)
+		}
+	}
+
+	stages := jenkinsStagePattern.FindAllStringSubmatchIndex(content, -1)
+	for _, stage := range stages {
+		pipeline.Jobs = append(pipeline.Jobs, models.CIJob{Name: content[stage[2]:stage[3]], Runners: runners})
+	}
+
+	// A matrix block belongs to the closest stage declared before it
+	if axes := jenkinsAxisPattern.FindAllStringSubmatchIndex(content, -1); len(axes) > 0 && len(pipeline.Jobs) > 0 {
+		size := 1
+		for _, axis := range axes {
+			size *= len(jenkinsQuotedPattern.FindAllString(content[axis[2]:axis[3]], -1))
+		}
+		owner := 0
+		for i, stage := range stages {
+			if stage[0] < axes[0][0] {
+				owner = i
+			}
+		}
+		pipeline.Jobs[owner].MatrixSize = size
+	}
+
+	for _, match := range jenkinsLibraryPattern.FindAllStringSubmatch(content, -1) {
+		pipeline.Actions = append(pipeline.Actions, newCIAction(firstNonEmpty(match[1], match[2]), "@"))
+	}
+	for _, match := range jenkinsCredsPattern.FindAllStringSubmatch(content, -1) {
+		pipeline.Secrets = append(pipeline.Secrets, firstNonEmpty(match[1], match[2]))
+	}
+}
+
+// --- CircleCI ---
+
+func parseCircleCI(pipeline *models.CIPipeline, content string) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return
+	}
+
+	orbs := yamlMap(config["orbs"])
+	for _, name := range sortedKeys(orbs) {
+		if ref, ok := orbs[name].(string); ok { // inline orbs are maps and live in this file
+			pipeline.Actions = append(pipeline.Actions, newCIAction(ref, "@"))
+		}
+	}
+
+	// Workflows decide triggers, matrices and contexts; jobs decide executors and steps
+	pipeline.Triggers = []string{"push"}
+	matrices := make(map[string]int)
+	workflows := yamlMap(config["workflows"])
+	for _, name := range sortedKeys(workflows) {
+		workflow := yamlMap(workflows[name])
+		if len(yamlList(workflow["triggers"])) > 0 {
+			pipeline.Triggers = append(pipeline.Triggers, "schedule")
+		}
+		for _, entry := range yamlList(workflow["jobs"]) {
+			for jobName, settings := range yamlMap(entry) {
+				spec := yamlMap(settings)
+				pipeline.Secrets = append(pipeline.Secrets, yamlStrings(spec["context"])...)
+				if parameters := yamlMap(yamlMap(spec["matrix"])["parameters"]); len(parameters) > 0 {
+					size := 1
+					for _, values := range parameters {
+						size *= len(yamlList(values))
+					}
+					matrices[jobName] += size
+				}
+			}
+		}
+	}
+
+	jobs := yamlMap(config["jobs"])
+	for _, id := range sortedKeys(jobs) {
+		spec := yamlMap(jobs[id])
+		job := models.CIJob{Name: id, MatrixSize: matrices[id], Steps: len(yamlList(spec["steps"]))}
+		switch {
+		case spec["docker"] != nil:
+			if image := yamlString(yamlMap(firstItem(spec["docker"]))["image"]); image != "" {
+				job.Runners = []string{image}
+			}
+		case spec["machine"] != nil:
+			job.Runners = []string{firstNonEmpty(yamlString(yamlMap(spec["machine"])["image"]), "machine")}
+		case spec["macos"] != nil:
+			job.Runners = []string{"macos"}
+		case spec["executor"] != nil:
+			job.Runners = []string{firstNonEmpty(yamlString(spec["executor"]), yamlString(yamlMap(spec["executor"])["name"]))}
+		}
+		pipeline.Jobs = append(pipeline.Jobs, job)
+	}
+}
+
+// --- Travis CI ---
+
+// travisMatrixKeys are the keys Travis expands into a build matrix
+var travisMatrixKeys = []string{"os", "arch", "env", "dist", "node_js", "python", "go", "ruby", "jdk", "php", "rust", "scala", "dart", "elixir", "otp_release", "perl", "r", "julia", "crystal", "dotnet", "mono"}
+
+func parseTravisCI(pipeline *models.CIPipeline, content string) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return
+	}
+
+	// Travis builds pushes and pull requests unless told otherwise in the repository settings
+	pipeline.Triggers = []string{"push", "pull_request"}
+
+	size := 1
+	for _, key := range travisMatrixKeys {
+		if list := yamlList(config[key]); len(list) > 1 && key != "env" {
+			size *= len(list)
+		}
+	}
+	// env can be a list of jobs or a {global, jobs} object
+	if list := yamlList(config["env"]); len(list) > 1 {
+		size *= len(list)
+	} else if jobs := yamlList(yamlMap(config["env"])["jobs"]); len(jobs) > 1 {
+		size *= len(jobs)
+	}
+
+	expansion := yamlMap(firstNonNil(config["jobs"], config["matrix"]))
+	size -= len(yamlList(expansion["exclude"]))
+	size += len(yamlList(expansion["include"]))
+
+	job := models.CIJob{
+		Name:    "build",
+		Runners: yamlStrings(config["os"]),
+		Steps:   len(yamlList(config["script"])),
+	}
+	if len(job.Runners) == 0 {
+		job.Runners = []string{"linux"}
+	}
+	if size > 1 {
+		job.MatrixSize = size
+	}
+	pipeline.Jobs = append(pipeline.Jobs, job)
+}
+
+// --- Azure Pipelines ---
+
+func parseAzurePipelines(pipeline *models.CIPipeline, content string) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return
+	}
+	pipeline.Name = yamlString(config["name"])
+
+	// Without a trigger/pr section Azure builds every push and pull request
+	if trigger, ok := config["trigger"]; !ok || yamlString(trigger) != "none" {
+		pipeline.Triggers = append(pipeline.Triggers, "push")
+	}
+	if pr, ok := config["pr"]; !ok || yamlString(pr) != "none" {
+		pipeline.Triggers = append(pipeline.Triggers, "pull_request")
+	}
+	if len(yamlList(config["schedules"])) > 0 {
+		pipeline.Triggers = append(pipeline.Triggers, "schedule")
+	}
+
+	repositories := make(map[string]string)
+	for _, entry := range yamlList(yamlMap(config["resources"])["repositories"]) {
+		repo := yamlMap(entry)
+		repositories[yamlString(repo["repository"])] = yamlString(repo["name"])
+		if repo["name"] != nil {
+			ref := yamlString(repo["ref"])
+			pipeline.Actions = append(pipeline.Actions, models.CIAction{Uses: yamlString(repo["name"]), Ref: ref, Pinning: classifyPin(strings.TrimPrefix(ref, "refs/tags/"))})
+		}
+	}
+
+	for _, variable := range yamlList(config["variables"]) {
+		if group := yamlString(yamlMap(variable)["group"]); group != "" {
+			pipeline.Secrets = append(pipeline.Secrets, group)
+		}
+	}
+
+	defaultPool := azurePool(config["pool"])
+	var jobs []interface{}
+	for _, stage := range yamlList(config["stages"]) {
+		jobs = append(jobs, yamlList(yamlMap(stage)["jobs"])...)
+	}
+	jobs = append(jobs, yamlList(config["jobs"])...)
+	if steps := config["steps"]; steps != nil {
+		jobs = append(jobs, map[string]interface{}{"job": "default", "steps": steps})
+	}
+
+	for _, entry := range jobs {
+		spec := yamlMap(entry)
+		job := models.CIJob{Name: firstNonEmpty(yamlString(spec["job"]), yamlString(spec["deployment"]), yamlString(spec["template"]))}
+		if pool := firstNonEmpty(azurePool(spec["pool"]), defaultPool); pool != "" {
+			job.Runners = []string{pool}
+		}
+
+		strategy := yamlMap(spec["strategy"])
+		if matrix := yamlMap(strategy["matrix"]); len(matrix) > 0 {
+			job.MatrixSize = len(matrix)
+		} else if n, ok := yamlInt(strategy["parallel"]); ok {
+			job.MatrixSize = n
+		}
+
+		steps := yamlList(spec["steps"])
+		job.Steps = len(steps)
+		for _, step := range steps {
+			task := yamlString(yamlMap(step)["task"])
+			if task == "" {
+				continue
+			}
+			action := newCIAction(task, "@")
+			// Built-in tasks have bare names; marketplace ones are publisher.extension.Task
+			action.FirstParty = !strings.Contains(action.Uses, ".")
+			pipeline.Actions = append(pipeline.Actions, action)
+		}
+		pipeline.Jobs = append(pipeline.Jobs, job)
+	}
+}
+
+func azurePool(value interface{}) string {
+	if pool := yamlMap(value); pool != nil {
+		return firstNonEmpty(yamlString(pool["vmImage"]), yamlString(pool["name"]))
+	}
+	return yamlString(value)
+}
+
+// --- Bitbucket Pipelines ---
+
+// bitbucketTriggers maps the sections under `pipelines:` to trigger names
+var bitbucketTriggers = map[string]string{
+	"default": "push", "branches": "push", "pull-requests": "pull_request", "tags": "tag", "custom": "manual",
+}
+
+func parseBitbucketPipelines(pipeline *models.CIPipeline, content string) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return
+	}
+
+	defaultImage := gitlabImage(config["image"])
+	sections := yamlMap(config["pipelines"])
+	for _, section := range sortedKeys(sections) {
+		if trigger, ok := bitbucketTriggers[section]; ok {
+			pipeline.Triggers = append(pipeline.Triggers, trigger)
+		}
+		walkBitbucketSteps(sections[section], func(step map[string]interface{}) {
+			job := models.CIJob{Name: yamlString(step["name"]), Runners: yamlStrings(step["runs-on"])}
+			if len(job.Runners) == 0 {
+				if image := firstNonEmpty(gitlabImage(step["image"]), defaultImage); image != "" {
+					job.Runners = []string{image}
+				}
+			}
+
+			script := yamlList(step["script"])
+			job.Steps = len(script)
+			for _, command := range script {
+				if pipe := yamlString(yamlMap(command)["pipe"]); pipe != "" {
+					action := dockerAction(pipe)
+					action.FirstParty = strings.HasPrefix(action.Uses, "atlassian/")
+					pipeline.Actions = append(pipeline.Actions, action)
+				}
+			}
+			pipeline.Jobs = append(pipeline.Jobs, job)
+		})
+	}
+}
+
+// walkBitbucketSteps finds every `step:` however deeply branches, parallel and stage blocks nest them
+func walkBitbucketSteps(value interface{}, visit func(step map[string]interface{})) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			walkBitbucketSteps(item, visit)
+		}
+	case map[string]interface{}:
+		if step := yamlMap(v["step"]); step != nil {
+			visit(step)
+			return
+		}
+		for _, key := range sortedKeys(v) {
+			walkBitbucketSteps(v[key], visit)
+		}
+	}
+}
+
+// --- YAML helpers ---
+
+func yamlMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func yamlList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+func yamlString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil, map[string]interface{}, []interface{}:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// yamlStrings reads a scalar, a list of scalars, or the keys of a mapping
+func yamlStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sortedKeys(v)
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if s := yamlString(item); s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	if s := yamlString(value); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func yamlInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func firstItem(value interface{}) interface{} {
+	if list := yamlList(value); len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+func firstNonNil(values ...interface{}) interface{} {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// uniqueSorted deduplicates a list of names and never returns nil
+func uniqueSorted(values []string) []string {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v != "" {
+			set[v] = true
+		}
+	}
+	return sortedKeys(set)
+}
@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// ApplyCommitHistory builds the commit-based sections of a report - contributors, the commit
+// timeline and the per-commit activity - from rows of the commits table, newest first
+func ApplyCommitHistory(report *models.AnalyticsReport, commits []models.Commit) {
+	totals := make(map[string]int)
+	avatars := make(map[string]string)
+	report.CommitTimeline = []time.Time{}
+	report.Commits = []models.CommitActivity{}
+
+	for _, commit := range commits {
+		author := CommitAuthor(commit)
+		if author != "" {
+			totals[author]++
+			if commit.AuthorAvatarURL != "" {
+				avatars[author] = commit.AuthorAvatarURL
+			}
+		}
+
+		local := AuthoredLocal(commit)
+		report.CommitTimeline = append(report.CommitTimeline, local)
+		report.Commits = append(report.Commits, models.CommitActivity{
+			SHA:                commit.SHA,
+			Author:             author,
+			Date:               local,
+			UTCOffset:          commit.AuthoredOffset,
+			OffsetKnown:        commit.OffsetKnown,
+			Signature:          commit.Signature,
+			Verified:           commit.Verified,
+			VerificationReason: commit.VerificationReason,
+		})
+	}
+
+	report.Contributors = []models.ContributorStats{}
+	for author, total := range totals {
+		contributor := models.ContributorStats{Total: total}
+		contributor.Author.Login = author
+		contributor.Author.AvatarURL = avatars[author]
+		report.Contributors = append(report.Contributors, contributor)
+	}
+	sort.Slice(report.Contributors, func(i, j int) bool {
+		if report.Contributors[i].Total != report.Contributors[j].Total {
+			return report.Contributors[i].Total > report.Contributors[j].Total
+		}
+		return report.Contributors[i].Author.Login < report.Contributors[j].Author.Login
+	})
+}
+
+// CommitAuthor is the GitHub login of a commit, or the git author name when the commit
+// isn't linked to an account
+func CommitAuthor(commit models.Commit) string {
+	if commit.AuthorLogin != "" {
+		return commit.AuthorLogin
+	}
+	return commit.AuthorName
+}
+
+// AuthoredLocal is the author date of a commit in the author's own timezone
+func AuthoredLocal(commit models.Commit) time.Time {
+	if !commit.OffsetKnown {
+		return commit.AuthoredAt.UTC()
+	}
+	return commit.AuthoredAt.In(time.FixedZone("", commit.AuthoredOffset*60))
+}
@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// manifestParsers maps a manifest/lockfile base name to the function that understands it.
+var manifestParsers = map[string]func(path, content string) []models.Dependency{
+	"go.mod":            parseGoMod,
+	"package.json":      parsePackageJSON,
+	"package-lock.json": parsePackageLock,
+	"pnpm-lock.yaml":    parsePnpmLock,
+	"yarn.lock":         parseYarnLock,
+	"requirements.txt":  parseRequirementsTxt,
+	"pyproject.toml":    parsePyproject,
+	"Cargo.toml":        parseCargoToml,
+	"Cargo.lock":        parseCargoLock,
+	"pom.xml":           parsePomXML,
+	"Gemfile":           parseGemfile,
+	"Gemfile.lock":      parseGemfileLock,
+}
+
+// ignoredDirs are vendored or generated folders whose manifests belong to someone else.
+var ignoredDirs = []string{"node_modules/", "vendor/", "third_party/", ".venv/", "venv/", "site-packages/"}
+
+// maxManifests caps how many manifests we fetch so huge monorepos don't burn the API quota.
+const maxManifests = 40
+
+// IsManifest reports whether a tree path is a dependency manifest or lockfile we can parse.
+func IsManifest(filePath string) bool {
+	if _, ok := manifestParsers[path.Base(filePath)]; !ok {
+		return false
+	}
+	return !isVendored(filePath)
+}
+
+// FindManifests picks the manifest paths out of a flat list of tree paths
+func FindManifests(paths []string) []string {
+	var manifests []string
+	for _, p := range paths {
+		if IsManifest(p) {
+			manifests = append(manifests, p)
+		}
+	}
+
+	// Shallow manifests first: the root project matters more than nested examples
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return strings.Count(manifests[i], "/") < strings.Count(manifests[j], "/")
+	})
+	if len(manifests) > maxManifests {
+		manifests = manifests[:maxManifests]
+	}
+	sort.Strings(manifests)
+	return manifests
+}
+
+// BuildDependencyInventory parses every manifest and lockfile and merges them per directory.
+// Manifests provide the direct dependencies with their declared constraints; lockfiles next
+// to them fill in resolved versions and contribute everything else as transitive.
+func BuildDependencyInventory(files map[string]string) models.DependencyInventory {
+	inventory := models.DependencyInventory{
+		Manifests:    []string{},
+		Dependencies: []models.Dependency{},
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		if IsManifest(p) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	// Group by directory and ecosystem so a lockfile only resolves its own manifest
+	type groupKey struct{ dir, ecosystem string }
+	direct := make(map[groupKey][]models.Dependency)
+	locked := make(map[groupKey][]models.Dependency)
+	var order []groupKey
+	seen := make(map[groupKey]bool)
+
+	for _, p := range paths {
+		parser := manifestParsers[path.Base(p)]
+		deps := parser(p, files[p])
+		inventory.Manifests = append(inventory.Manifests, p)
+
+		for _, dep := range deps {
+			key := groupKey{dir: path.Dir(p), ecosystem: dep.Ecosystem}
+			if !seen[key] {
+				seen[key] = true
+				order = append(order, key)
+			}
+			if dep.Direct {
+				direct[key] = append(direct[key], dep)
+			} else {
+				locked[key] = append(locked[key], dep)
+			}
+		}
+	}
+
+	for _, key := range order {
+		index := make(map[string]int)
+		var merged []models.Dependency
+
+		for _, dep := range direct[key] {
+			name := normalizeName(dep.Ecosystem, dep.Name)
+			if i, ok := index[name]; ok {
+				// Same package declared twice (e.g. Gemfile and pyproject extras) - keep the first
+				if merged[i].Version == "" {
+					merged[i].Version = dep.Version
+				}
+				continue
+			}
+			index[name] = len(merged)
+			merged = append(merged, dep)
+		}
+
+		// The first locked copy of a direct dependency resolves it; any other
+		// version of the same package is a nested transitive install
+		lockedSeen := make(map[string]bool)
+		for _, dep := range locked[key] {
+			name := normalizeName(dep.Ecosystem, dep.Name)
+			if i, ok := index[name]; ok && merged[i].Resolved == "" {
+				merged[i].Resolved = dep.Version
+				if merged[i].License == "" {
+					merged[i].License = dep.License
+				}
+				continue
+			}
+			if i, ok := index[name]; (ok && merged[i].Resolved == dep.Version) || lockedSeen[name+"@"+dep.Version] {
+				continue
+			}
+			lockedSeen[name+"@"+dep.Version] = true
+			merged = append(merged, dep)
+		}
+
+		inventory.Dependencies = append(inventory.Dependencies, merged...)
+	}
+
+	for _, dep := range inventory.Dependencies {
+		if dep.Direct {
+			inventory.DirectCount++
+		} else {
+			inventory.TransitiveCount++
+		}
+	}
+
+	return inventory
+}
+
+// pypiSeparators are the runs PEP 503 treats as a single "-"
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizeName applies each ecosystem's own name comparison rules
+func normalizeName(ecosystem, name string) string {
+	switch ecosystem {
+	case "pypi":
+		// PEP 503: case-insensitive, runs of -, _ and . are equivalent
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case "cargo":
+		return strings.ReplaceAll(name, "_", "-")
+	}
+	return name
+}
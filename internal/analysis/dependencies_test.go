@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		ecosystem, name, want string
+	}{
+		{"pypi", "Django", "django"},
+		{"pypi", "Flask_SQLAlchemy", "flask-sqlalchemy"},
+		{"pypi", "zope.interface", "zope-interface"},
+		{"pypi", "Foo__Bar", "foo-bar"},
+		{"pypi", "foo-._bar", "foo-bar"},
+		{"cargo", "serde_json", "serde-json"},
+		{"npm", "@Scope/Name", "@Scope/Name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ecosystem+"/"+tt.name, func(t *testing.T) {
+			if got := normalizeName(tt.ecosystem, tt.name); got != tt.want {
+				t.Errorf("normalizeName(%q, %q) = %q, want %q", tt.ecosystem, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDependencyInventory(t *testing.T) {
+	tests := []struct {
+		name               string
+		files              map[string]string
+		want               []string
+		direct, transitive int
+	}{
+		{
+			name: "lockfile resolves its own manifest",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"debug": "^4.3.0", "@scope/pkg": "^1.0.0"}}`,
+				"package-lock.json": `{"lockfileVersion": 3, "packages": {
+					"": {},
+					"node_modules/debug": {"version": "4.3.4", "license": "MIT"},
+					"node_modules/ms": {"version": "2.1.2"},
+					"node_modules/send/node_modules/debug": {"version": "2.6.9"},
+					"node_modules/@scope/pkg": {"version": "1.2.0"}
+				}}`,
+			},
+			want: []string{
+				"@scope/pkg ^1.0.0->1.2.0 direct ",
+				"debug ^4.3.0->4.3.4 direct MIT",
+				"ms 2.1.2 transitive ",
+				"debug 2.6.9 transitive ",
+			},
+			direct: 2, transitive: 2,
+		},
+		{
+			name: "lockfile in another directory stays separate",
+			files: map[string]string{
+				"package.json":     `{"dependencies": {"debug": "^4.3.0"}}`,
+				"web/yarn.lock":    "debug@^4.3.0:\n  version \"4.3.4\"\n",
+				"web/package.json": `{"dependencies": {"react": "^18.0.0"}}`,
+			},
+			want: []string{
+				"debug ^4.3.0 direct ",
+				"react ^18.0.0 direct ",
+				"debug 4.3.4 transitive ",
+			},
+			direct: 2, transitive: 1,
+		},
+		{
+			name: "PEP 503 duplicates merge",
+			files: map[string]string{
+				"requirements.txt": "Flask_SQLAlchemy\nzope.interface==6.0\n",
+				"pyproject.toml": `[project]
+dependencies = ["flask-sqlalchemy>=3.0", "Zope_Interface"]
+`,
+			},
+			want: []string{
+				"flask-sqlalchemy >=3.0 direct ",
+				"Zope_Interface ==6.0 direct ",
+			},
+			direct: 2, transitive: 0,
+		},
+		{
+			name: "vendored manifests are ignored",
+			files: map[string]string{
+				"go.mod":                             "module example.com/app\n\nrequire github.com/pkg/errors v0.9.1\n",
+				"vendor/github.com/x/y/go.mod":       "module github.com/x/y\n\nrequire golang.org/x/text v0.3.0\n",
+				"node_modules/left-pad/package.json": `{"dependencies": {"a": "1"}}`,
+			},
+			want:   []string{"github.com/pkg/errors v0.9.1 direct "},
+			direct: 1, transitive: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := BuildDependencyInventory(tt.files)
+
+			got := []string{}
+			for _, dep := range inventory.Dependencies {
+				version := dep.Version
+				if dep.Resolved != "" {
+					version += "->" + dep.Resolved
+				}
+				kind := "transitive"
+				if dep.Direct {
+					kind = "direct"
+				}
+				got = append(got, fmt.Sprintf("%s %s %s %s", dep.Name, version, kind, dep.License))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencies = %q, want %q", got, tt.want)
+			}
+			if inventory.DirectCount != tt.direct || inventory.TransitiveCount != tt.transitive {
+				t.Errorf("counts = %d direct, %d transitive, want %d, %d",
+					inventory.DirectCount, inventory.TransitiveCount, tt.direct, tt.transitive)
+			}
+		})
+	}
+}
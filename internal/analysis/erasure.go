@@ -0,0 +1,226 @@
+package analysis
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// ErasedPlaceholder replaces an erased identity inside free text such as chat transcripts
+const ErasedPlaceholder = "[erased]"
+
+// identityHashKey is the server secret IdentityHash is keyed with, set once at startup
+var identityHashKey []byte
+
+// SetIdentityHashKey sets the secret for IdentityHash. Without it, anyone holding the erasure
+// log could confirm a guessed login or email by hashing it. Changing it makes earlier
+// erasures stop matching new analyses.
+func SetIdentityHashKey(key string) {
+	identityHashKey = []byte(key)
+}
+
+// IdentityHash is how an erased login, name or email is remembered: an HMAC-SHA256 of its
+// trimmed, lowercased form. "" for a blank identity, which never matches.
+func IdentityHash(identity string) string {
+	normalized := strings.ToLower(strings.TrimSpace(identity))
+	if normalized == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, identityHashKey)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isErased reports whether an identity is in a set of IdentityHash values
+func isErased(identity string, erased map[string]bool) bool {
+	hash := IdentityHash(identity)
+	return hash != "" && erased[hash]
+}
+
+// EraseCommitAuthor blanks the author of a commits table row when any of its login, name or
+// email is erased. The commit itself stays, so counts and timelines keep adding up.
+func EraseCommitAuthor(commit *models.Commit, erased map[string]bool) bool {
+	if !isErased(commit.AuthorLogin, erased) && !isErased(commit.AuthorName, erased) && !isErased(commit.AuthorEmail, erased) {
+		return false
+	}
+	commit.AuthorLogin = ""
+	commit.AuthorName = ""
+	commit.AuthorEmail = ""
+	commit.AuthorAvatarURL = ""
+	return true
+}
+
+// EraseContributor removes erased identities from every section of a report that names
+// contributors. Per-person entries are dropped; per-commit entries keep the commit without
+// an author. It returns how many entries were changed.
+func EraseContributor(report *models.AnalyticsReport, erased map[string]bool) int {
+	changed := 0
+
+	contributors := report.Contributors[:0]
+	for _, contributor := range report.Contributors {
+		if isErased(contributor.Author.Login, erased) {
+			changed++
+			continue
+		}
+		contributors = append(contributors, contributor)
+	}
+	report.Contributors = contributors
+
+	for i := range report.Commits {
+		if isErased(report.Commits[i].Author, erased) {
+			report.Commits[i].Author = ""
+			changed++
+		}
+	}
+
+	hours := report.WorkingHours.Contributors[:0]
+	for _, contributor := range report.WorkingHours.Contributors {
+		if isErased(contributor.Author, erased) {
+			changed++
+			continue
+		}
+		hours = append(hours, contributor)
+	}
+	report.WorkingHours.Contributors = hours
+
+	for i := range report.WorkingHours.OverlapWindows {
+		window := &report.WorkingHours.OverlapWindows[i]
+		names := window.Contributors[:0]
+		for _, name := range window.Contributors {
+			if isErased(name, erased) {
+				changed++
+				continue
+			}
+			names = append(names, name)
+		}
+		window.Contributors = names
+	}
+
+	for i := range report.Workspace.Packages {
+		pkg := &report.Workspace.Packages[i]
+		packageContributors := pkg.Contributors[:0]
+		for _, contributor := range pkg.Contributors {
+			if isErased(contributor.Login, erased) {
+				changed++
+				continue
+			}
+			packageContributors = append(packageContributors, contributor)
+		}
+		pkg.Contributors = packageContributors
+	}
+
+	signers := report.Signatures.Contributors[:0]
+	for _, contributor := range report.Signatures.Contributors {
+		if isErased(contributor.Author, erased) {
+			changed++
+			continue
+		}
+		signers = append(signers, contributor)
+	}
+	report.Signatures.Contributors = signers
+
+	for i := range report.Signatures.UnverifiedOnProtected {
+		if isErased(report.Signatures.UnverifiedOnProtected[i].Author, erased) {
+			report.Signatures.UnverifiedOnProtected[i].Author = ""
+			changed++
+		}
+	}
+
+	return changed
+}
+
+// RedactIdentities replaces whole-word, case-insensitive mentions of the identities in
+// free text with ErasedPlaceholder
+func RedactIdentities(text string, identities []string) string {
+	pattern := identityPattern(identities)
+	if pattern == nil {
+		return text
+	}
+	return pattern.ReplaceAllString(text, ErasedPlaceholder)
+}
+
+// identityPattern matches any of the identities, longest first so an email wins over the
+// name inside it. Word boundaries are only required where the identity starts or ends
+// with a word character.
+func identityPattern(identities []string) *regexp.Regexp {
+	var alternatives []string
+	for _, identity := range identities {
+		identity = strings.TrimSpace(identity)
+		if identity == "" {
+			continue
+		}
+		alternative := regexp.QuoteMeta(identity)
+		if isWordByte(identity[0]) {
+			alternative = `\b` + alternative
+		}
+		if isWordByte(identity[len(identity)-1]) {
+			alternative += `\b`
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		return len(alternatives[i]) > len(alternatives[j])
+	})
+	return regexp.MustCompile(`(?i)` + strings.Join(alternatives, "|"))
+}
+
+// maxNameWords is the longest run of words RedactErased compares against erased names
+const maxNameWords = 4
+
+// identityToken is a word, login or email: dots and @ only inside it, so "octocat." at the
+// end of a sentence is still "octocat"
+var identityToken = regexp.MustCompile(`[\p{L}\p{N}_%+-]+(?:[.@][\p{L}\p{N}_%+-]+)*`)
+
+// RedactErased is RedactIdentities for when only the hashes of the identities are known, as
+// for commits ingested after an erasure. Every token, and every run of up to maxNameWords
+// space-separated tokens (for full names), is hashed and replaced when it is erased.
+func RedactErased(text string, erased map[string]bool) string {
+	if len(erased) == 0 {
+		return text
+	}
+	tokens := identityToken.FindAllStringIndex(text, -1)
+
+	var redacted strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); i++ {
+		// Longest run first, so a full name wins over its first name
+		for words := min(maxNameWords, len(tokens)-i); words > 0; words-- {
+			end := i + words - 1
+			if !spaceSeparated(text, tokens[i:end+1]) || !isErased(text[tokens[i][0]:tokens[end][1]], erased) {
+				continue
+			}
+			redacted.WriteString(text[last:tokens[i][0]])
+			redacted.WriteString(ErasedPlaceholder)
+			last = tokens[end][1]
+			i = end
+			break
+		}
+	}
+	if last == 0 {
+		return text
+	}
+	redacted.WriteString(text[last:])
+	return redacted.String()
+}
+
+// spaceSeparated reports whether consecutive tokens are only separated by single spaces
+func spaceSeparated(text string, tokens [][]int) bool {
+	for i := 1; i < len(tokens); i++ {
+		if text[tokens[i-1][1]:tokens[i][0]] != " " {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
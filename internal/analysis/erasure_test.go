@@ -0,0 +1,117 @@
+package analysis
+
+import "testing"
+
+func TestRedactIdentities(t *testing.T) {
+	identities := []string{"octocat", "The Octocat", "octocat@example.com", "Mona Lisa Octo"}
+	tests := []struct {
+		name, text, want string
+	}{
+		{
+			name: "co-authored-by trailer",
+			text: "Fix login\n\nCo-authored-by: The Octocat <octocat@example.com>",
+			want: "Fix login\n\nCo-authored-by: [erased] <[erased]>",
+		},
+		{
+			name: "case differences",
+			text: "Thanks OCTOCAT and the octocat, mail OctoCat@Example.COM",
+			want: "Thanks [erased] and [erased], mail [erased]",
+		},
+		{
+			name: "multi-word name",
+			text: "Reviewed-by: Mona Lisa Octo",
+			want: "Reviewed-by: [erased]",
+		},
+		{
+			name: "mention and sentence end",
+			text: "ping @octocat. done",
+			want: "ping @[erased]. done",
+		},
+		{
+			name: "unrelated words keep their substrings",
+			text: "octocats and superoctocat use octocat_bot, see theoctocat@example.community",
+			want: "octocats and superoctocat use octocat_bot, see theoctocat@example.community",
+		},
+		{
+			name: "no identities mentioned",
+			text: "Bump dependencies",
+			want: "Bump dependencies",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactIdentities(tt.text, identities); got != tt.want {
+				t.Errorf("RedactIdentities(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactErased(t *testing.T) {
+	SetIdentityHashKey("test-key")
+	t.Cleanup(func() { SetIdentityHashKey("") })
+
+	erased := map[string]bool{}
+	for _, identity := range []string{"octocat", "The Octocat", "octocat@example.com", "Mona Lisa Octo"} {
+		erased[IdentityHash(identity)] = true
+	}
+	tests := []struct {
+		name, text, want string
+	}{
+		{
+			name: "co-authored-by trailer",
+			text: "Fix login\n\nCo-authored-by: The Octocat <octocat@example.com>",
+			want: "Fix login\n\nCo-authored-by: [erased] <[erased]>",
+		},
+		{
+			name: "case differences",
+			text: "Thanks OCTOCAT, mail OctoCat@Example.COM",
+			want: "Thanks [erased], mail [erased]",
+		},
+		{
+			name: "multi-word name wins over its parts",
+			text: "Reviewed-by: Mona Lisa Octo and The Octocat",
+			want: "Reviewed-by: [erased] and [erased]",
+		},
+		{
+			name: "sentence end",
+			text: "Reported by octocat.",
+			want: "Reported by [erased].",
+		},
+		{
+			name: "unrelated words keep their substrings",
+			text: "octocats and superoctocat use octocat_bot, see octocat-cli",
+			want: "octocats and superoctocat use octocat_bot, see octocat-cli",
+		},
+		{
+			name: "name split across lines is not a name",
+			text: "Mona\nLisa Octo",
+			want: "Mona\nLisa Octo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactErased(tt.text, erased); got != tt.want {
+				t.Errorf("RedactErased(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentityHash(t *testing.T) {
+	SetIdentityHashKey("test-key")
+	t.Cleanup(func() { SetIdentityHashKey("") })
+
+	if IdentityHash("  OctoCat ") != IdentityHash("octocat") {
+		t.Error("IdentityHash is not case and space insensitive")
+	}
+	if IdentityHash("") != "" || IdentityHash("   ") != "" {
+		t.Error("IdentityHash of a blank identity is not empty")
+	}
+
+	keyed := IdentityHash("octocat")
+	SetIdentityHashKey("other-key")
+	if IdentityHash("octocat") == keyed {
+		t.Error("IdentityHash does not depend on the key")
+	}
+}
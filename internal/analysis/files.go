@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// sourceExtensions are the file types we treat as hand-written code
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".rs": true, ".java": true, ".kt": true, ".scala": true, ".swift": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".php": true,
+	".vue": true, ".svelte": true, ".dart": true, ".ex": true, ".exs": true, ".sh": true,
+}
+
+// IsSourceFile reports whether a path looks like source code (outside vendored folders)
+func IsSourceFile(filePath string) bool {
+	if !sourceExtensions[strings.ToLower(path.Ext(filePath))] {
+		return false
+	}
+	return !isVendored(filePath)
+}
+
+// isVendored reports whether a path sits inside a vendored or generated folder
+func isVendored(filePath string) bool {
+	for _, dir := range ignoredDirs {
+		if strings.HasPrefix(filePath, dir) || strings.Contains(filePath, "/"+dir) {
+			return true
+		}
+	}
+	return strings.Contains(filePath, ".min.")
+}
+
+// SelectSourceSample picks up to limit source files, shallowest first, so the
+// files closest to the project root (entry points, core packages) are covered
+func SelectSourceSample(paths []string, limit int) []string {
+	var sample []string
+	for _, p := range paths {
+		if IsSourceFile(p) {
+			sample = append(sample, p)
+		}
+	}
+
+	sort.SliceStable(sample, func(i, j int) bool {
+		return strings.Count(sample[i], "/") < strings.Count(sample[j], "/")
+	})
+	if len(sample) > limit {
+		sample = sample[:limit]
+	}
+	sort.Strings(sample)
+	return sample
+}
@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"path"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// communityDirs are where GitHub looks for community health files, in its order of precedence
+var communityDirs = []string{"", ".github/", "docs/"}
+
+// healthCheck is one item of the checklist. Weights add up to 100.
+type healthCheck struct {
+	id     string
+	label  string
+	weight int
+	why    string
+	names  []string // Lowercased base names without extension; matched in communityDirs
+	dirs   []string // Folders whose presence also satisfies the check (template directories)
+}
+
+var healthChecks = []healthCheck{
+	{
+		id: "readme", label: "README", weight: 20, names: []string{"readme"},
+		why: "The README is the first thing visitors and judges read: what the project does and how to run it.",
+	},
+	{
+		id: "license", label: "License", weight: 15, names: []string{"license", "licence", "copying"},
+		why: "Without a license nobody else may legally use, change or share the code.",
+	},
+	{
+		id: "contributing", label: "Contributing guide", weight: 10, names: []string{"contributing"},
+		why: "Tells new contributors how to set up, what to work on and how changes get reviewed.",
+	},
+	{
+		id: "code_of_conduct", label: "Code of conduct", weight: 10, names: []string{"code_of_conduct", "code-of-conduct"},
+		why: "Sets expectations for behavior and gives people a way to report problems.",
+	},
+	{
+		id: "security_policy", label: "Security policy", weight: 10, names: []string{"security"},
+		why: "Tells researchers how to report vulnerabilities privately instead of in a public issue.",
+	},
+	{
+		id: "issue_templates", label: "Issue templates", weight: 8, names: []string{"issue_template"}, dirs: []string{"issue_template"},
+		why: "Templates get bug reports with versions and reproduction steps instead of one-liners.",
+	},
+	{
+		id: "pull_request_template", label: "Pull request template", weight: 7, names: []string{"pull_request_template"}, dirs: []string{"pull_request_template"},
+		why: "A PR checklist reminds contributors about tests, docs and linked issues.",
+	},
+	{
+		id: "changelog", label: "Changelog", weight: 8, names: []string{"changelog", "changes", "history", "news", "releases"},
+		why: "Users need to know what changed between versions before they upgrade.",
+	},
+	{
+		id: "description", label: "Repository description", weight: 6,
+		why: "The one-line description shows up in search results and link previews.",
+	},
+	{
+		id: "topics", label: "Repository topics", weight: 6,
+		why: "Topics make the project discoverable when people browse GitHub by subject.",
+	},
+}
+
+// BuildHealthReport scores a repository against the community health checklist using only
+// the tree and the repository metadata - no model calls, so the same repo always scores the same
+func BuildHealthReport(paths []string, repo models.Repository) models.HealthReport {
+	report := models.HealthReport{Checks: []models.HealthCheck{}, Missing: []string{}}
+
+	for _, check := range healthChecks {
+		result := models.HealthCheck{ID: check.id, Label: check.label, Weight: check.weight, Why: check.why}
+		switch check.id {
+		case "description":
+			result.Passed = strings.TrimSpace(repo.Description) != ""
+		case "topics":
+			result.Passed = len(repo.Topics) > 0
+		case "license":
+			result.Path = findCommunityFile(paths, check)
+			result.Passed = result.Path != "" || repo.License != nil && repo.License.SPDXID != "" && repo.License.SPDXID != "NOASSERTION"
+		default:
+			result.Path = findCommunityFile(paths, check)
+			result.Passed = result.Path != ""
+		}
+
+		if result.Passed {
+			report.Score += check.weight
+		} else {
+			report.Missing = append(report.Missing, check.id)
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// findCommunityFile returns the first path satisfying a check, looking in the root, .github/
+// and docs/ like GitHub does; names match case-insensitively and with any extension
+func findCommunityFile(paths []string, check healthCheck) string {
+	for _, dir := range communityDirs {
+		for _, p := range paths {
+			lower := strings.ToLower(p)
+			if !strings.HasPrefix(lower, dir) {
+				continue
+			}
+			rest := strings.TrimPrefix(lower, dir)
+
+			// A template directory: .github/ISSUE_TEMPLATE/bug_report.md
+			if first, _, nested := strings.Cut(rest, "/"); nested {
+				for _, name := range check.dirs {
+					if first == name {
+						return p[:len(dir)+len(first)]
+					}
+				}
+				continue
+			}
+
+			base := strings.TrimSuffix(rest, path.Ext(rest))
+			for _, name := range check.names {
+				if base == name {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// velocityWindowWeeks is how far back commit velocity looks from each analysis
+const velocityWindowWeeks = 12
+
+// SummarizeReport is the history entry of a stored report
+func SummarizeReport(report *models.AnalyticsReport) models.ReportSnapshot {
+	return models.ReportSnapshot{
+		ID:           report.ID,
+		GeneratedAt:  report.GeneratedAt,
+		CommitSHA:    report.CommitSHA,
+		Snapshot:     report.Snapshot,
+		SnapshotRef:  report.SnapshotRef,
+		SnapshotDate: report.SnapshotDate,
+		Stars:        report.RepoInfo.Stars,
+		Forks:        report.RepoInfo.Forks,
+		OpenIssues:   report.RepoInfo.OpenIssues,
+		HealthScore:  report.Health.Score,
+	}
+}
+
+// DiffReports compares two analyses of the same repository: popularity, contributors,
+// language mix, commit velocity and the scores of the report sections
+func DiffReports(from, to *models.AnalyticsReport) models.ReportDiff {
+	velocity := models.VelocityDelta{
+		WindowWeeks: velocityWindowWeeks,
+		From:        commitVelocity(from),
+		To:          commitVelocity(to),
+	}
+	velocity.Delta = round2(velocity.To - velocity.From)
+
+	return models.ReportDiff{
+		From:         SummarizeReport(from),
+		To:           SummarizeReport(to),
+		Stars:        countDelta(from.RepoInfo.Stars, to.RepoInfo.Stars),
+		Forks:        countDelta(from.RepoInfo.Forks, to.RepoInfo.Forks),
+		OpenIssues:   countDelta(from.RepoInfo.OpenIssues, to.RepoInfo.OpenIssues),
+		Contributors: diffContributors(from.Contributors, to.Contributors),
+		Languages:    diffLanguageShares(from.RepoInfo.Languages, to.RepoInfo.Languages),
+		Velocity:     velocity,
+		Scores:       diffScores(from, to),
+	}
+}
+
+func countDelta(from, to int) models.CountDelta {
+	return models.CountDelta{From: from, To: to, Delta: to - from}
+}
+
+func diffContributors(from, to []models.ContributorStats) models.ContributorDelta {
+	before := make(map[string]bool)
+	for _, contributor := range from {
+		before[contributor.Author.Login] = true
+	}
+	after := make(map[string]bool)
+	for _, contributor := range to {
+		after[contributor.Author.Login] = true
+	}
+
+	delta := models.ContributorDelta{From: len(before), To: len(after), Added: []string{}, Removed: []string{}}
+	delta.Delta = delta.To - delta.From
+	for login := range after {
+		if !before[login] {
+			delta.Added = append(delta.Added, login)
+		}
+	}
+	for login := range before {
+		if !after[login] {
+			delta.Removed = append(delta.Removed, login)
+		}
+	}
+	sort.Strings(delta.Added)
+	sort.Strings(delta.Removed)
+	return delta
+}
+
+// diffLanguageShares compares each language's percentage of all code bytes
+func diffLanguageShares(from, to map[string]int) []models.LanguageShareDelta {
+	fromTotal, toTotal := 0, 0
+	for _, bytes := range from {
+		fromTotal += bytes
+	}
+	for _, bytes := range to {
+		toTotal += bytes
+	}
+	share := func(bytes, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return round2(float64(bytes) * 100 / float64(total))
+	}
+
+	languages := make(map[string]bool)
+	for language := range from {
+		languages[language] = true
+	}
+	for language := range to {
+		languages[language] = true
+	}
+
+	deltas := []models.LanguageShareDelta{}
+	for language := range languages {
+		delta := models.LanguageShareDelta{
+			Language:  language,
+			FromBytes: from[language],
+			ToBytes:   to[language],
+			FromShare: share(from[language], fromTotal),
+			ToShare:   share(to[language], toTotal),
+		}
+		delta.ShareDelta = round2(delta.ToShare - delta.FromShare)
+		deltas = append(deltas, delta)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		a, b := math.Abs(deltas[i].ShareDelta), math.Abs(deltas[j].ShareDelta)
+		if a != b {
+			return a > b
+		}
+		return deltas[i].Language < deltas[j].Language
+	})
+	return deltas
+}
+
+// commitVelocity is commits per week over the window before the analyzed revision
+func commitVelocity(report *models.AnalyticsReport) float64 {
+	end := report.GeneratedAt
+	if report.SnapshotDate != nil {
+		end = *report.SnapshotDate
+	}
+	start := end.Add(-velocityWindowWeeks * 7 * 24 * time.Hour)
+
+	commits := 0
+	for _, date := range report.CommitTimeline {
+		if date.After(start) && !date.After(end) {
+			commits++
+		}
+	}
+	return round2(float64(commits) / velocityWindowWeeks)
+}
+
+// diffScores lists the headline number of each report section, in a fixed order
+func diffScores(from, to *models.AnalyticsReport) []models.ScoreDelta {
+	scores := []struct {
+		name  string
+		value func(*models.AnalyticsReport) float64
+	}{
+		{"health_score", func(r *models.AnalyticsReport) float64 { return float64(r.Health.Score) }},
+		{"test_ratio", func(r *models.AnalyticsReport) float64 { return r.Testing.Ratio }},
+		{"verified_commit_rate", func(r *models.AnalyticsReport) float64 { return r.Signatures.VerifiedRate }},
+		{"direct_dependencies", func(r *models.AnalyticsReport) float64 { return float64(r.Dependencies.DirectCount) }},
+		{"vulnerabilities", func(r *models.AnalyticsReport) float64 { return float64(len(r.Vulnerabilities.Findings)) }},
+		{"high_severity_vulnerabilities", func(r *models.AnalyticsReport) float64 { return float64(r.Vulnerabilities.HighSeverityCount) }},
+		{"secret_findings", func(r *models.AnalyticsReport) float64 { return float64(len(r.Secrets.Findings)) }},
+		{"license_conflicts", func(r *models.AnalyticsReport) float64 { return float64(len(r.Licenses.Conflicts)) }},
+		{"unpinned_ci_actions", func(r *models.AnalyticsReport) float64 { return float64(r.CI.UnpinnedActions) }},
+	}
+
+	deltas := make([]models.ScoreDelta, 0, len(scores))
+	for _, score := range scores {
+		before, after := score.value(from), score.value(to)
+		deltas = append(deltas, models.ScoreDelta{Name: score.name, From: before, To: after, Delta: round2(after - before)})
+	}
+	return deltas
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
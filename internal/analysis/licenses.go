@@ -0,0 +1,390 @@
+package analysis
+
+import (
+	"embed"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// The corpus holds the reference texts of common SPDX licenses, one file per SPDX id.
+// Long licenses (Apache, GPL family, MPL...) are trimmed to their title and opening
+// sections, which is where they differ from each other.
+//
+//go:embed licenses/*.txt
+var licenseCorpusFS embed.FS
+
+// Minimum share of a reference text that must appear in a file to call it a match
+const licenseMatchThreshold = 0.75
+
+// maxLicenseFiles caps how many LICENSE/COPYING files we fetch
+const maxLicenseFiles = 10
+
+type licenseTemplate struct {
+	id       string
+	shingles map[string]bool
+}
+
+var (
+	licenseCorpus     []licenseTemplate
+	licenseCorpusOnce sync.Once
+)
+
+func loadLicenseCorpus() []licenseTemplate {
+	licenseCorpusOnce.Do(func() {
+		entries, _ := licenseCorpusFS.ReadDir("licenses")
+		for _, entry := range entries {
+			data, err := licenseCorpusFS.ReadFile("licenses/" + entry.Name())
+			if err != nil {
+				continue
+			}
+			licenseCorpus = append(licenseCorpus, licenseTemplate{
+				id:       strings.TrimSuffix(entry.Name(), ".txt"),
+				shingles: licenseShingles(string(data)),
+			})
+		}
+	})
+	return licenseCorpus
+}
+
+// IsLicenseFile reports whether a path is a LICENSE/LICENCE/COPYING style file
+func IsLicenseFile(filePath string) bool {
+	if isVendored(filePath) {
+		return false
+	}
+	base := strings.ToUpper(path.Base(filePath))
+	return strings.HasPrefix(base, "LICENSE") || strings.HasPrefix(base, "LICENCE") || strings.HasPrefix(base, "COPYING")
+}
+
+// FindLicenseFiles picks the license files out of a flat list of tree paths, root ones first
+func FindLicenseFiles(paths []string) []string {
+	var files []string
+	for _, p := range paths {
+		if IsLicenseFile(p) {
+			files = append(files, p)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.Count(files[i], "/") < strings.Count(files[j], "/")
+	})
+	if len(files) > maxLicenseFiles {
+		files = files[:maxLicenseFiles]
+	}
+	return files
+}
+
+// DetectLicense matches a license text against the embedded corpus and returns the
+// SPDX id with its confidence, or "" when nothing matched well enough
+func DetectLicense(text string) (string, float64) {
+	shingles := licenseShingles(text)
+	if len(shingles) == 0 {
+		return "", 0
+	}
+
+	bestID, bestScore, bestSize := "", 0.0, 0
+	for _, template := range loadLicenseCorpus() {
+		found := 0
+		for shingle := range template.shingles {
+			if shingles[shingle] {
+				found++
+			}
+		}
+		score := float64(found) / float64(len(template.shingles))
+
+		// On a near tie prefer the longer reference: BSD-3-Clause contains all of BSD-2-Clause
+		better := score > bestScore+0.02 ||
+			(score >= bestScore-0.02 && len(template.shingles) > bestSize && score >= licenseMatchThreshold)
+		if better {
+			bestID, bestScore, bestSize = template.id, score, len(template.shingles)
+		}
+	}
+
+	if bestScore < licenseMatchThreshold {
+		return "", bestScore
+	}
+	return bestID, bestScore
+}
+
+// licenseShingles normalizes a text to lowercase words (dropping copyright lines,
+// punctuation and layout) and returns its set of 3-word shingles
+func licenseShingles(text string) map[string]bool {
+	var words []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "copyright") || strings.Contains(trimmed, "(c)") {
+			continue
+		}
+		words = append(words, strings.FieldsFunc(trimmed, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+		})...)
+	}
+
+	shingles := make(map[string]bool)
+	for i := 0; i+2 < len(words); i++ {
+		shingles[words[i]+" "+words[i+1]+" "+words[i+2]] = true
+	}
+	return shingles
+}
+
+// spdxHeaderPattern finds SPDX-License-Identifier declarations in file headers
+var spdxHeaderPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+)`)
+
+// ScanLicenseHeaders collects SPDX-License-Identifier headers from the first lines of each file
+func ScanLicenseHeaders(files map[string]string) []models.LicenseHeader {
+	headers := []models.LicenseHeader{}
+
+	for _, filePath := range sortedKeys(files) {
+		lines := strings.SplitN(files[filePath], "\n", 31)
+		if len(lines) > 30 {
+			lines = lines[:30]
+		}
+		for _, line := range lines {
+			match := spdxHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			license := strings.TrimSpace(match[1])
+			for _, closer := range []string{"*/", "-->", "#}", "--%>"} {
+				license = strings.TrimSpace(strings.TrimSuffix(license, closer))
+			}
+			if license != "" {
+				headers = append(headers, models.LicenseHeader{Path: filePath, License: license})
+			}
+			break
+		}
+	}
+	return headers
+}
+
+// BuildLicenseReport works out the project license - from its LICENSE files first,
+// falling back to GitHub's guess - and checks every dependency license against it
+func BuildLicenseReport(githubLicense *models.RepoLicense, licenseFiles, sourceFiles map[string]string, deps models.DependencyInventory) models.LicenseReport {
+	report := models.LicenseReport{
+		Source:       "none",
+		LicenseFiles: []models.LicenseFile{},
+		FileHeaders:  ScanLicenseHeaders(sourceFiles),
+		Conflicts:    []models.LicenseConflict{},
+		FilesScanned: len(sourceFiles),
+	}
+
+	// Root-level license files decide the project license
+	var rootLicenses []string
+	for _, filePath := range sortedKeys(licenseFiles) {
+		id, confidence := DetectLicense(licenseFiles[filePath])
+		report.LicenseFiles = append(report.LicenseFiles, models.LicenseFile{
+			Path:       filePath,
+			License:    id,
+			Confidence: float64(int(confidence*100)) / 100,
+		})
+		if id != "" && !strings.Contains(filePath, "/") && !containsString(rootLicenses, id) {
+			rootLicenses = append(rootLicenses, id)
+		}
+	}
+
+	switch {
+	case len(rootLicenses) > 0:
+		report.Project = combineRootLicenses(rootLicenses)
+		report.Source = "license_file"
+	case githubLicense != nil && githubLicense.SPDXID != "" && githubLicense.SPDXID != "NOASSERTION":
+		report.Project = githubLicense.SPDXID
+		report.Source = "github"
+	}
+
+	for _, dep := range deps.Dependencies {
+		if dep.License == "" {
+			continue
+		}
+		if severity, reason := licenseConflict(report.Project, dep.License); severity != "" {
+			report.Conflicts = append(report.Conflicts, models.LicenseConflict{
+				Dependency: dep.Name,
+				Ecosystem:  dep.Ecosystem,
+				License:    dep.License,
+				Severity:   severity,
+				Reason:     reason,
+			})
+		}
+	}
+
+	return report
+}
+
+// combineRootLicenses turns several root license files into one expression.
+// COPYING + COPYING.LESSER is how LGPL projects ship, anything else is dual licensing.
+func combineRootLicenses(ids []string) string {
+	for _, id := range ids {
+		if strings.HasPrefix(id, "LGPL") {
+			return id
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, " OR ")
+}
+
+// License categories, from least to most restrictive
+const (
+	licensePermissive = iota
+	licenseWeakCopyleft
+	licenseStrongCopyleft
+	licenseNetworkCopyleft
+	licenseUnknown
+)
+
+var licenseCategories = map[string]int{
+	"MIT": licensePermissive, "ISC": licensePermissive, "BSD-2-Clause": licensePermissive,
+	"BSD-3-Clause": licensePermissive, "0BSD": licensePermissive, "Apache-2.0": licensePermissive,
+	"Unlicense": licensePermissive, "CC0-1.0": licensePermissive, "Zlib": licensePermissive,
+	"BSL-1.0": licensePermissive, "Python-2.0": licensePermissive, "BlueOak-1.0.0": licensePermissive,
+	"WTFPL": licensePermissive, "CC-BY-4.0": licensePermissive,
+	"LGPL-2.0": licenseWeakCopyleft, "LGPL-2.1": licenseWeakCopyleft, "LGPL-3.0": licenseWeakCopyleft,
+	"MPL-2.0": licenseWeakCopyleft, "EPL-1.0": licenseWeakCopyleft, "EPL-2.0": licenseWeakCopyleft,
+	"CDDL-1.0": licenseWeakCopyleft,
+	"GPL-2.0":  licenseStrongCopyleft, "GPL-3.0": licenseStrongCopyleft,
+	"AGPL-3.0": licenseNetworkCopyleft,
+}
+
+// licenseFamily strips the -only / -or-later / + suffixes: "GPL-3.0-or-later" -> "GPL-3.0"
+func licenseFamily(id string) string {
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
+
+func licenseCategory(id string) int {
+	if strings.Contains(id, " WITH ") {
+		// Linking exceptions (Classpath, GCC runtime...) make a copyleft license library-friendly
+		return licenseWeakCopyleft
+	}
+	if category, ok := licenseCategories[licenseFamily(id)]; ok {
+		return category
+	}
+	return licenseUnknown
+}
+
+// licenseConflict checks a dependency license (which may be an SPDX expression) against
+// the project license. It returns "" when they are compatible or we can't tell.
+func licenseConflict(project, dependency string) (string, string) {
+	// With "A OR B" the licensee picks, so the least severe combination is the one that counts
+	projectOptions := licenseAlternatives(project)
+	if len(projectOptions) == 0 {
+		projectOptions = [][]string{{""}}
+	}
+
+	bestSeverity, bestReason := "", ""
+	first := true
+	for _, projectTerms := range projectOptions {
+		for _, depTerms := range licenseAlternatives(dependency) {
+			severity, reason := "", ""
+			for _, p := range projectTerms {
+				for _, d := range depTerms {
+					s, r := licensePairConflict(p, d)
+					if severityRank(s) > severityRank(severity) {
+						severity, reason = s, r
+					}
+				}
+			}
+			if first || severityRank(severity) < severityRank(bestSeverity) {
+				bestSeverity, bestReason = severity, reason
+				first = false
+			}
+		}
+	}
+	return bestSeverity, bestReason
+}
+
+// licensePairConflict compares two plain SPDX ids
+func licensePairConflict(project, dep string) (string, string) {
+	projectFamily, depFamily := licenseFamily(project), licenseFamily(dep)
+	projectCategory, depCategory := licenseCategory(project), licenseCategory(dep)
+	projectName := project
+	if projectName == "" {
+		projectName = "unlicensed"
+	}
+
+	switch {
+	case depCategory == licenseUnknown:
+		return "", ""
+
+	case depCategory == licenseNetworkCopyleft && projectFamily != "AGPL-3.0":
+		return "high", "AGPL code inside this " + projectName + " project: offering it over a network requires publishing the combined source under the AGPL"
+
+	case depCategory == licenseStrongCopyleft && project == "":
+		return "medium", dep + " dependency in a project with no license: the combined work can only be distributed under the GPL"
+
+	case depCategory == licenseStrongCopyleft && projectCategory < licenseStrongCopyleft:
+		return "high", dep + " dependency inside this " + projectName + " project: distributing the combined work requires releasing it under the GPL"
+
+	case depFamily == "GPL-3.0" && projectFamily == "GPL-2.0":
+		if strings.HasSuffix(project, "-or-later") || strings.HasSuffix(project, "+") {
+			return "medium", "GPLv3 dependency forces this GPLv2-or-later project to be distributed under GPLv3"
+		}
+		return "high", "GPLv3 code cannot be distributed under GPLv2 only"
+
+	case depFamily == "GPL-2.0" && strings.HasSuffix(dep, "-only") && (projectFamily == "GPL-3.0" || projectFamily == "AGPL-3.0"):
+		return "high", "GPLv2-only code cannot be relicensed under " + project
+
+	case depFamily == "Apache-2.0" && projectFamily == "GPL-2.0" && !strings.HasSuffix(project, "-or-later") && !strings.HasSuffix(project, "+"):
+		return "high", "Apache-2.0 patent and indemnity terms are incompatible with GPLv2"
+
+	case depCategory == licenseWeakCopyleft && projectCategory == licensePermissive:
+		return "medium", dep + " dependency inside this " + projectName + " project: changes to the dependency itself must stay under " + dep + ", and static linking may carry extra obligations"
+	}
+	return "", ""
+}
+
+// licenseAlternatives splits an SPDX expression into OR-alternatives of AND-ed ids:
+// "(MIT OR Apache-2.0) AND BSD-3-Clause" is flattened loosely into [[MIT BSD-3-Clause] [Apache-2.0 BSD-3-Clause]]
+func licenseAlternatives(expression string) [][]string {
+	expression = strings.TrimSpace(strings.NewReplacer("(", " ", ")", " ").Replace(expression))
+	if expression == "" {
+		return nil
+	}
+
+	var andTerms []string
+	var orGroups [][]string
+	for _, part := range strings.Split(expression, " AND ") {
+		options := strings.Split(part, " OR ")
+		if len(options) == 1 {
+			andTerms = append(andTerms, strings.TrimSpace(part))
+			continue
+		}
+		for i := range options {
+			options[i] = strings.TrimSpace(options[i])
+		}
+		orGroups = append(orGroups, options)
+	}
+
+	if len(orGroups) == 0 {
+		return [][]string{andTerms}
+	}
+
+	var result [][]string
+	for _, group := range orGroups {
+		for _, option := range group {
+			result = append(result, append(append([]string{}, andTerms...), option))
+		}
+	}
+	return result
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "high":
+		return 2
+	case "medium":
+		return 1
+	}
+	return 0
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,642 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/mod/modfile"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// Each parser is best-effort: a malformed manifest yields whatever could be read, never an error,
+// because one broken example project shouldn't hide the rest of the inventory.
+
+// --- Go ---
+
+func parseGoMod(path, content string) []models.Dependency {
+	file, err := modfile.ParseLax(path, []byte(content), nil)
+	if err != nil {
+		return nil
+	}
+
+	var deps []models.Dependency
+	for _, req := range file.Require {
+		deps = append(deps, models.Dependency{
+			Name:      req.Mod.Path,
+			Ecosystem: "go",
+			Version:   req.Mod.Version,
+			Direct:    !req.Indirect,
+			Scope:     "runtime",
+			Source:    path,
+		})
+	}
+	return deps
+}
+
+// --- JavaScript ---
+
+func parsePackageJSON(path, content string) []models.Dependency {
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+
+	var deps []models.Dependency
+	add := func(section map[string]string, scope string) {
+		for _, name := range sortedKeys(section) {
+			deps = append(deps, models.Dependency{
+				Name:      name,
+				Ecosystem: "npm",
+				Version:   section[name],
+				Direct:    true,
+				Scope:     scope,
+				Source:    path,
+			})
+		}
+	}
+	add(pkg.Dependencies, "runtime")
+	add(pkg.DevDependencies, "dev")
+	add(pkg.PeerDependencies, "peer")
+	add(pkg.OptionalDependencies, "optional")
+	return deps
+}
+
+type npmLockV1Entry struct {
+	Version      string                    `json:"version"`
+	Dev          bool                      `json:"dev"`
+	Dependencies map[string]npmLockV1Entry `json:"dependencies"`
+}
+
+func parsePackageLock(path, content string) []models.Dependency {
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+			Dev     bool   `json:"dev"`
+			Link    bool   `json:"link"`
+			License string `json:"license"`
+		} `json:"packages"`
+		Dependencies map[string]npmLockV1Entry `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+
+	collector := newLockCollector("npm", path)
+
+	// lockfileVersion 2/3: flat "packages" keyed by install path
+	if len(lock.Packages) > 0 {
+		// Hoisted installs first, so the top-level copy is the one that resolves a direct dependency
+		keys := sortedKeys(lock.Packages)
+		sort.SliceStable(keys, func(i, j int) bool {
+			return strings.Count(keys[i], "node_modules/") < strings.Count(keys[j], "node_modules/")
+		})
+		for _, key := range keys {
+			entry := lock.Packages[key]
+			idx := strings.LastIndex(key, "node_modules/")
+			if idx < 0 || entry.Link {
+				continue // root project or a workspace symlink
+			}
+			if dep := collector.add(key[idx+len("node_modules/"):], entry.Version, entry.Dev); dep != nil {
+				dep.License = entry.License
+			}
+		}
+		return collector.deps
+	}
+
+	// lockfileVersion 1: nested "dependencies"
+	var walk func(map[string]npmLockV1Entry)
+	walk = func(entries map[string]npmLockV1Entry) {
+		for _, name := range sortedKeys(entries) {
+			entry := entries[name]
+			collector.add(name, entry.Version, entry.Dev)
+			walk(entry.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return collector.deps
+}
+
+func parsePnpmLock(path, content string) []models.Dependency {
+	var lock struct {
+		Packages map[string]struct {
+			Dev bool `yaml:"dev"`
+		} `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+
+	collector := newLockCollector("npm", path)
+	for _, key := range sortedKeys(lock.Packages) {
+		name, version := splitPnpmKey(key)
+		collector.add(name, version, lock.Packages[key].Dev)
+	}
+	return collector.deps
+}
+
+// splitPnpmKey handles every pnpm key style we've seen:
+// v5 "/name/1.0.0_peer@2", v6 "/@scope/name@1.0.0(peer@2)", v9 "name@1.0.0"
+func splitPnpmKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.Index(key, "("); i >= 0 {
+		key = key[:i]
+	}
+
+	// The name ends at the first "/" or "@" after its scope
+	start := 0
+	if strings.HasPrefix(key, "@") {
+		start = strings.Index(key, "/") + 1
+		if start == 0 {
+			return key, ""
+		}
+	}
+	end := strings.IndexAny(key[start:], "/@")
+	if end < 0 {
+		return key, ""
+	}
+	end += start
+
+	version := key[end+1:]
+	if key[end] == '/' {
+		// v5 appends peers after an underscore
+		if i := strings.Index(version, "_"); i >= 0 {
+			version = version[:i]
+		}
+	}
+	return key[:end], version
+}
+
+func parseYarnLock(path, content string) []models.Dependency {
+	collector := newLockCollector("npm", path)
+
+	var currentName string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Entry header: `"a@^1.0.0", a@^1.1.0:` (classic) or `"a@npm:^1.0.0":` (berry)
+		if !strings.HasPrefix(line, " ") {
+			currentName = ""
+			spec := strings.TrimSuffix(line, ":")
+			spec = strings.TrimSpace(strings.Split(spec, ",")[0])
+			spec = strings.Trim(spec, `"`)
+			if spec == "" || spec == "__metadata" || strings.Contains(spec, "@workspace:") {
+				continue
+			}
+			if at := strings.Index(spec[1:], "@"); at >= 0 {
+				currentName = spec[:at+1]
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if currentName != "" && strings.HasPrefix(trimmed, "version") {
+			version := strings.TrimPrefix(trimmed, "version")
+			version = strings.Trim(strings.TrimSpace(strings.TrimPrefix(version, ":")), `"`)
+			collector.add(currentName, version, false)
+			currentName = ""
+		}
+	}
+	return collector.deps
+}
+
+// --- Python ---
+
+// requirementPattern matches the name part of a PEP 508 requirement, e.g. "Django[argon2]>=4.2"
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+
+// parseRequirementSpec splits a PEP 508 requirement into name and version constraint
+func parseRequirementSpec(spec string) (string, string) {
+	if i := strings.Index(spec, ";"); i >= 0 {
+		spec = spec[:i] // environment markers
+	}
+	match := requirementPattern.FindStringSubmatch(strings.TrimSpace(spec))
+	if match == nil {
+		return "", ""
+	}
+	version := strings.TrimSpace(match[3])
+	if strings.HasPrefix(version, "@") {
+		version = "" // direct URL reference, no version
+	}
+	return match[1], strings.ReplaceAll(version, " ", "")
+}
+
+func parseRequirementsTxt(path, content string) []models.Dependency {
+	var deps []models.Dependency
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		// Skip comments, pip options (-r, -e, --hash...) and bare paths/URLs
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") ||
+			strings.Contains(line, "://") || strings.HasPrefix(line, ".") || strings.HasPrefix(line, "/") {
+			continue
+		}
+
+		name, version := parseRequirementSpec(line)
+		if name == "" {
+			continue
+		}
+		deps = append(deps, models.Dependency{
+			Name:      name,
+			Ecosystem: "pypi",
+			Version:   version,
+			Direct:    true,
+			Scope:     "runtime",
+			Source:    path,
+		})
+	}
+	return deps
+}
+
+func parsePyproject(path, content string) []models.Dependency {
+	var doc struct {
+		Project struct {
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		DependencyGroups map[string][]interface{} `toml:"dependency-groups"`
+		Tool             struct {
+			Poetry struct {
+				Dependencies    map[string]interface{} `toml:"dependencies"`
+				DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]interface{} `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil
+	}
+
+	var deps []models.Dependency
+	addSpec := func(spec, scope string) {
+		name, version := parseRequirementSpec(spec)
+		if name == "" {
+			return
+		}
+		deps = append(deps, models.Dependency{Name: name, Ecosystem: "pypi", Version: version, Direct: true, Scope: scope, Source: path})
+	}
+	addPoetry := func(section map[string]interface{}, scope string) {
+		for _, name := range sortedKeys(section) {
+			if strings.EqualFold(name, "python") {
+				continue // interpreter constraint, not a package
+			}
+			deps = append(deps, models.Dependency{Name: name, Ecosystem: "pypi", Version: tableVersion(section[name]), Direct: true, Scope: scope, Source: path})
+		}
+	}
+
+	// PEP 621
+	for _, spec := range doc.Project.Dependencies {
+		addSpec(spec, "runtime")
+	}
+	for _, extra := range sortedKeys(doc.Project.OptionalDependencies) {
+		for _, spec := range doc.Project.OptionalDependencies[extra] {
+			addSpec(spec, "optional")
+		}
+	}
+
+	// PEP 735 - entries can also be {include-group = "..."} tables, which we skip
+	for _, group := range sortedKeys(doc.DependencyGroups) {
+		for _, entry := range doc.DependencyGroups[group] {
+			if spec, ok := entry.(string); ok {
+				addSpec(spec, group)
+			}
+		}
+	}
+
+	// Poetry
+	addPoetry(doc.Tool.Poetry.Dependencies, "runtime")
+	addPoetry(doc.Tool.Poetry.DevDependencies, "dev")
+	for _, group := range sortedKeys(doc.Tool.Poetry.Group) {
+		addPoetry(doc.Tool.Poetry.Group[group].Dependencies, group)
+	}
+
+	return deps
+}
+
+// --- Rust ---
+
+func parseCargoToml(path, content string) []models.Dependency {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil
+	}
+
+	var deps []models.Dependency
+	addSection := func(section interface{}, scope string) {
+		table, ok := section.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, key := range sortedKeys(table) {
+			name := key
+			if spec, ok := table[key].(map[string]interface{}); ok {
+				if renamed, ok := spec["package"].(string); ok {
+					name = renamed
+				}
+				if inherited, _ := spec["workspace"].(bool); inherited {
+					continue // resolved from [workspace.dependencies] instead
+				}
+			}
+			deps = append(deps, models.Dependency{Name: name, Ecosystem: "cargo", Version: tableVersion(table[key]), Direct: true, Scope: scope, Source: path})
+		}
+	}
+
+	sections := map[string]string{"dependencies": "runtime", "dev-dependencies": "dev", "build-dependencies": "build"}
+	for _, section := range []string{"dependencies", "dev-dependencies", "build-dependencies"} {
+		addSection(doc[section], sections[section])
+	}
+	if workspace, ok := doc["workspace"].(map[string]interface{}); ok {
+		addSection(workspace["dependencies"], "runtime")
+	}
+	// Platform-specific tables: [target.'cfg(windows)'.dependencies]
+	if targets, ok := doc["target"].(map[string]interface{}); ok {
+		for _, target := range sortedKeys(targets) {
+			if table, ok := targets[target].(map[string]interface{}); ok {
+				for _, section := range []string{"dependencies", "dev-dependencies", "build-dependencies"} {
+					addSection(table[section], sections[section])
+				}
+			}
+		}
+	}
+	return deps
+}
+
+func parseCargoLock(path, content string) []models.Dependency {
+	var lock struct {
+		Package []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  string `toml:"source"`
+		} `toml:"package"`
+	}
+	if err := toml.Unmarshal([]byte(content), &lock); err != nil {
+		return nil
+	}
+
+	collector := newLockCollector("cargo", path)
+	for _, pkg := range lock.Package {
+		if pkg.Source == "" {
+			continue // a crate of this workspace, not a dependency
+		}
+		collector.add(pkg.Name, pkg.Version, false)
+	}
+	return collector.deps
+}
+
+// --- Java ---
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+func parsePomXML(path, content string) []models.Dependency {
+	var pom struct {
+		GroupID string `xml:"groupId"`
+		Version string `xml:"version"`
+		Parent  struct {
+			GroupID string `xml:"groupId"`
+			Version string `xml:"version"`
+		} `xml:"parent"`
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Dependencies []pomDependency `xml:"dependencies>dependency"`
+		Managed      []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	}
+	if err := xml.Unmarshal([]byte(content), &pom); err != nil {
+		return nil
+	}
+
+	props := map[string]string{
+		"project.groupId": firstNonEmpty(pom.GroupID, pom.Parent.GroupID),
+		"project.version": firstNonEmpty(pom.Version, pom.Parent.Version),
+	}
+	for _, entry := range pom.Properties.Entries {
+		props[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	resolve := func(value string) string {
+		return propertyPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+
+	managed := make(map[string]string)
+	for _, dep := range pom.Managed {
+		managed[resolve(dep.GroupID)+":"+resolve(dep.ArtifactID)] = resolve(dep.Version)
+	}
+
+	var deps []models.Dependency
+	for _, dep := range pom.Dependencies {
+		name := resolve(dep.GroupID) + ":" + resolve(dep.ArtifactID)
+		version := resolve(dep.Version)
+		if version == "" {
+			version = managed[name]
+		}
+		scope := dep.Scope
+		if scope == "" || scope == "compile" {
+			scope = "runtime"
+		}
+		deps = append(deps, models.Dependency{Name: name, Ecosystem: "maven", Version: version, Direct: true, Scope: scope, Source: path})
+	}
+	return deps
+}
+
+var propertyPattern = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// --- Ruby ---
+
+var (
+	gemPattern      = regexp.MustCompile(`^gem\s+['"]([^'"]+)['"]((?:\s*,\s*['"][^'"]*['"])*)`)
+	gemGroupPattern = regexp.MustCompile(`^group\s+(.+?)\s+do\b`)
+	gemInlineGroup  = regexp.MustCompile(`(?:group|groups):\s*\[?\s*:(\w+)`)
+	gemLockSpec     = regexp.MustCompile(`^    (\S+) \(([^)]+)\)$`)
+)
+
+func parseGemfile(path, content string) []models.Dependency {
+	var deps []models.Dependency
+	var blocks []string // scope of every open `do` block, "" for non-group blocks
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if i := strings.Index(trimmed, "#"); i >= 0 {
+			trimmed = strings.TrimSpace(trimmed[:i])
+		}
+
+		switch {
+		case trimmed == "end":
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		case gemGroupPattern.MatchString(trimmed):
+			group := gemGroupPattern.FindStringSubmatch(trimmed)[1]
+			blocks = append(blocks, gemScope(strings.TrimLeft(strings.Split(group, ",")[0], ": ")))
+			continue
+		case strings.HasSuffix(trimmed, " do") || strings.Contains(trimmed, " do |"):
+			blocks = append(blocks, "")
+			continue
+		}
+
+		match := gemPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+
+		var constraints []string
+		for _, part := range strings.Split(match[2], ",") {
+			if part = strings.Trim(strings.TrimSpace(part), `'"`); part != "" {
+				constraints = append(constraints, part)
+			}
+		}
+
+		scope := "runtime"
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if blocks[i] != "" {
+				scope = blocks[i]
+				break
+			}
+		}
+		if inline := gemInlineGroup.FindStringSubmatch(trimmed); inline != nil {
+			scope = gemScope(inline[1])
+		}
+
+		deps = append(deps, models.Dependency{
+			Name:      match[1],
+			Ecosystem: "rubygems",
+			Version:   strings.Join(constraints, ", "),
+			Direct:    true,
+			Scope:     scope,
+			Source:    path,
+		})
+	}
+	return deps
+}
+
+// gemScope maps Bundler group names onto our scope vocabulary
+func gemScope(group string) string {
+	switch group {
+	case "development":
+		return "dev"
+	case "default":
+		return "runtime"
+	}
+	return group
+}
+
+func parseGemfileLock(path, content string) []models.Dependency {
+	collector := newLockCollector("rubygems", path)
+
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		if line != "" && !strings.HasPrefix(line, " ") {
+			section = strings.TrimSpace(line)
+			continue
+		}
+		// PATH specs are local gems of this project
+		if section != "GEM" && section != "GIT" {
+			continue
+		}
+		if match := gemLockSpec.FindStringSubmatch(line); match != nil {
+			collector.add(match[1], match[2], false)
+		}
+	}
+	return collector.deps
+}
+
+// --- helpers ---
+
+// lockCollector dedupes name@version pairs, since lockfiles list a package once per install path
+type lockCollector struct {
+	ecosystem string
+	source    string
+	seen      map[string]bool
+	deps      []models.Dependency
+}
+
+func newLockCollector(ecosystem, source string) *lockCollector {
+	return &lockCollector{ecosystem: ecosystem, source: source, seen: make(map[string]bool)}
+}
+
+// add records a locked package and returns it, or nil when it was already seen
+func (lc *lockCollector) add(name, version string, dev bool) *models.Dependency {
+	if name == "" || lc.seen[name+"@"+version] {
+		return nil
+	}
+	lc.seen[name+"@"+version] = true
+
+	scope := ""
+	if dev {
+		scope = "dev"
+	}
+	lc.deps = append(lc.deps, models.Dependency{
+		Name:      name,
+		Ecosystem: lc.ecosystem,
+		Version:   version,
+		Direct:    false,
+		Scope:     scope,
+		Source:    lc.source,
+	})
+	return &lc.deps[len(lc.deps)-1]
+}
+
+// tableVersion reads a version from either `dep = "1.0"` or `dep = { version = "1.0", ... }`
+func tableVersion(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if version, ok := v["version"].(string); ok {
+			return version
+		}
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,256 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// depSummaries flattens dependencies to "name version scope" for compact comparisons
+func depSummaries(deps []models.Dependency) []string {
+	summaries := []string{}
+	for _, dep := range deps {
+		summaries = append(summaries, fmt.Sprintf("%s %s %s", dep.Name, dep.Version, dep.Scope))
+	}
+	return summaries
+}
+
+func TestSplitPnpmKey(t *testing.T) {
+	tests := []struct {
+		key, name, version string
+	}{
+		{"/lodash/4.17.21", "lodash", "4.17.21"},
+		{"/@babel/core/7.22.5", "@babel/core", "7.22.5"},
+		{"/react-dom/18.2.0_react@18.2.0", "react-dom", "18.2.0"},
+		{"/@testing-library/react/14.0.0_biqbaboplfbrettd7655fr4n2y", "@testing-library/react", "14.0.0"},
+		{"/lodash@4.17.21", "lodash", "4.17.21"},
+		{"/@babel/core@7.22.5", "@babel/core", "7.22.5"},
+		{"/react-dom@18.2.0(react@18.2.0)", "react-dom", "18.2.0"},
+		{"/@scope/name@1.0.0(peer@2.0.0)(other@3.0.0)", "@scope/name", "1.0.0"},
+		{"lodash@4.17.21", "lodash", "4.17.21"},
+		{"@babel/core@7.22.5", "@babel/core", "7.22.5"},
+		{"react-dom@18.2.0(react@18.2.0)", "react-dom", "18.2.0"},
+		{"lodash", "lodash", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			name, version := splitPnpmKey(tt.key)
+			if name != tt.name || version != tt.version {
+				t.Errorf("splitPnpmKey(%q) = (%q, %q), want (%q, %q)", tt.key, name, version, tt.name, tt.version)
+			}
+		})
+	}
+}
+
+func TestParseNpmManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(path, content string) []models.Dependency
+		content string
+		want    []string
+	}{
+		{
+			name:  "package.json scopes",
+			parse: parsePackageJSON,
+			content: `{
+				"dependencies": {"@types/node": "^20.0.0", "react": "^18.2.0"},
+				"devDependencies": {"@babel/core": "^7.22.0"},
+				"peerDependencies": {"react-dom": ">=18"},
+				"optionalDependencies": {"fsevents": "^2.3.0"}
+			}`,
+			want: []string{
+				"@types/node ^20.0.0 runtime",
+				"react ^18.2.0 runtime",
+				"@babel/core ^7.22.0 dev",
+				"react-dom >=18 peer",
+				"fsevents ^2.3.0 optional",
+			},
+		},
+		{
+			name:  "package-lock v3 hoisted and nested",
+			parse: parsePackageLock,
+			content: `{
+				"lockfileVersion": 3,
+				"packages": {
+					"": {"name": "app"},
+					"node_modules/@babel/core": {"version": "7.22.5", "dev": true, "license": "MIT"},
+					"node_modules/debug": {"version": "4.3.4"},
+					"node_modules/send/node_modules/debug": {"version": "2.6.9"},
+					"node_modules/send/node_modules/@scope/pkg": {"version": "1.0.0"},
+					"node_modules/app-shared": {"link": true}
+				}
+			}`,
+			want: []string{
+				"@babel/core 7.22.5 dev",
+				"debug 4.3.4 ",
+				"@scope/pkg 1.0.0 ",
+				"debug 2.6.9 ",
+			},
+		},
+		{
+			name:  "package-lock v1 nested dependencies",
+			parse: parsePackageLock,
+			content: `{
+				"lockfileVersion": 1,
+				"dependencies": {
+					"@scope/pkg": {"version": "1.0.0", "dependencies": {"inner": {"version": "0.1.0"}}},
+					"jest": {"version": "29.0.0", "dev": true}
+				}
+			}`,
+			want: []string{
+				"@scope/pkg 1.0.0 ",
+				"inner 0.1.0 ",
+				"jest 29.0.0 dev",
+			},
+		},
+		{
+			name:  "pnpm v5",
+			parse: parsePnpmLock,
+			content: `lockfileVersion: 5.4
+packages:
+  /@babel/core/7.22.5:
+    dev: true
+  /react-dom/18.2.0_react@18.2.0:
+    dev: false
+`,
+			want: []string{"@babel/core 7.22.5 dev", "react-dom 18.2.0 "},
+		},
+		{
+			name:  "pnpm v6",
+			parse: parsePnpmLock,
+			content: `lockfileVersion: '6.0'
+packages:
+  /@babel/core@7.22.5:
+    dev: true
+  /react-dom@18.2.0(react@18.2.0):
+    dev: false
+`,
+			want: []string{"@babel/core 7.22.5 dev", "react-dom 18.2.0 "},
+		},
+		{
+			name:  "pnpm v9",
+			parse: parsePnpmLock,
+			content: `lockfileVersion: '9.0'
+packages:
+  '@babel/core@7.22.5':
+    resolution: {integrity: sha512-abc}
+  react-dom@18.2.0:
+    resolution: {integrity: sha512-def}
+`,
+			want: []string{"@babel/core 7.22.5 ", "react-dom 18.2.0 "},
+		},
+		{
+			name:  "yarn classic",
+			parse: parseYarnLock,
+			content: `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0", "@babel/core@^7.22.0":
+  version "7.22.5"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.22.5.tgz"
+
+lodash@^4.17.21:
+  version "4.17.21"
+  resolved "https://registry.yarnpkg.com/lodash/-/lodash-4.17.21.tgz"
+`,
+			want: []string{"@babel/core 7.22.5 ", "lodash 4.17.21 "},
+		},
+		{
+			name:  "yarn berry",
+			parse: parseYarnLock,
+			content: `__metadata:
+  version: 6
+  cacheKey: 8
+
+"@babel/core@npm:^7.0.0, @babel/core@npm:^7.22.0":
+  version: 7.22.5
+  resolution: "@babel/core@npm:7.22.5"
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+
+"resolve@patch:resolve@^1.22.0#~builtin<compat/resolve>":
+  version: 1.22.2
+  resolution: "resolve@patch:resolve@npm%3A1.22.2#~builtin<compat/resolve>::version=1.22.2"
+`,
+			want: []string{"@babel/core 7.22.5 ", "resolve 1.22.2 "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := depSummaries(tt.parse("app/lock", tt.content))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePythonManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(path, content string) []models.Dependency
+		content string
+		want    []string
+	}{
+		{
+			name:  "requirements.txt",
+			parse: parseRequirementsTxt,
+			content: `# pinned
+-r base.txt
+--hash=sha256:abc
+Django[argon2] >= 4.2 ; python_version > "3.8"
+requests==2.31.0  # http
+Flask_SQLAlchemy
+git+https://github.com/org/repo.git
+./local-package
+pkg @ https://example.com/pkg.whl
+`,
+			want: []string{
+				"Django >=4.2 runtime",
+				"requests ==2.31.0 runtime",
+				"Flask_SQLAlchemy  runtime",
+			},
+		},
+		{
+			name:  "pyproject PEP 621, PEP 735 and Poetry",
+			parse: parsePyproject,
+			content: `[project]
+dependencies = ["Django>=4.2", "zope.interface"]
+
+[project.optional-dependencies]
+argon = ["argon2-cffi>=21.0"]
+
+[dependency-groups]
+test = ["pytest>=7", {include-group = "lint"}]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+requests = { version = "^2.31", extras = ["socks"] }
+
+[tool.poetry.group.docs.dependencies]
+mkdocs = "^1.5"
+`,
+			want: []string{
+				"Django >=4.2 runtime",
+				"zope.interface  runtime",
+				"argon2-cffi >=21.0 optional",
+				"pytest >=7 test",
+				"requests ^2.31 runtime",
+				"mkdocs ^1.5 docs",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := depSummaries(tt.parse("app/manifest", tt.content))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
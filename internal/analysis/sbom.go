@@ -0,0 +1,355 @@
+package analysis
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+const sbomToolName = "hacker-introspector"
+
+// --- CycloneDX 1.5 ---
+
+type CycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     CycloneDXMetadata     `json:"metadata"`
+	Components   []CycloneDXComponent  `json:"components"`
+	Dependencies []CycloneDXDependency `json:"dependencies"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []CycloneDXTool    `json:"tools"`
+	Component CycloneDXComponent `json:"component"`
+}
+
+type CycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+type CycloneDXComponent struct {
+	Type     string             `json:"type"`
+	BOMRef   string             `json:"bom-ref"`
+	Name     string             `json:"name"`
+	Group    string             `json:"group,omitempty"`
+	Version  string             `json:"version,omitempty"`
+	Scope    string             `json:"scope,omitempty"`
+	Purl     string             `json:"purl,omitempty"`
+	Licenses []CycloneDXLicense `json:"licenses,omitempty"`
+}
+
+// CycloneDXLicense holds either a single SPDX id or a compound expression, never both
+type CycloneDXLicense struct {
+	License    *CycloneDXLicenseID `json:"license,omitempty"`
+	Expression string              `json:"expression,omitempty"`
+}
+
+type CycloneDXLicenseID struct {
+	ID string `json:"id"`
+}
+
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// --- SPDX 2.3 ---
+
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// sbomComponent is the format-neutral view both exporters are built from
+type sbomComponent struct {
+	dep     models.Dependency
+	version string
+	purl    string
+}
+
+// BuildCycloneDX exports the report's dependency inventory as a CycloneDX 1.5 JSON BOM.
+// The analyzed commit SHA becomes the version of the root component.
+func BuildCycloneDX(report *models.AnalyticsReport) *CycloneDXBOM {
+	root := CycloneDXComponent{
+		Type:     "application",
+		BOMRef:   rootPurl(report),
+		Name:     report.RepoInfo.FullName,
+		Version:  report.CommitSHA,
+		Purl:     rootPurl(report),
+		Licenses: cycloneDXLicenses(report.Licenses.Project),
+	}
+
+	bom := &CycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + deterministicUUID(report.RepoInfo.FullName, sbomVersionKey(report)),
+		Version:      1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: report.GeneratedAt.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Name: sbomToolName}},
+			Component: root,
+		},
+		Components:   []CycloneDXComponent{},
+		Dependencies: []CycloneDXDependency{},
+	}
+
+	direct := []string{}
+	for _, c := range sbomComponents(report.Dependencies) {
+		scope := "required"
+		if c.dep.Scope == "dev" || c.dep.Scope == "test" || c.dep.Scope == "optional" {
+			scope = "optional"
+		}
+		bom.Components = append(bom.Components, CycloneDXComponent{
+			Type:     "library",
+			BOMRef:   c.purl,
+			Name:     c.dep.Name,
+			Version:  c.version,
+			Scope:    scope,
+			Purl:     c.purl,
+			Licenses: cycloneDXLicenses(c.dep.License),
+		})
+		if c.dep.Direct {
+			direct = append(direct, c.purl)
+		}
+	}
+
+	bom.Dependencies = append(bom.Dependencies, CycloneDXDependency{Ref: root.BOMRef, DependsOn: direct})
+	return bom
+}
+
+// BuildSPDX exports the report's dependency inventory as an SPDX 2.3 JSON document.
+// The analyzed commit SHA is the root package version and part of the document namespace.
+func BuildSPDX(report *models.AnalyticsReport) *SPDXDocument {
+	name := report.RepoInfo.FullName
+	if report.CommitSHA != "" {
+		name += "@" + report.CommitSHA
+	}
+
+	doc := &SPDXDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://github.com/%s/sbom/%s", report.RepoInfo.FullName, sbomVersionKey(report)),
+		CreationInfo: SPDXCreationInfo{
+			Created:  report.GeneratedAt.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + sbomToolName},
+		},
+	}
+
+	rootID := "SPDXRef-Package-root"
+	doc.Packages = append(doc.Packages, SPDXPackage{
+		Name:             report.RepoInfo.FullName,
+		SPDXID:           rootID,
+		VersionInfo:      report.CommitSHA,
+		DownloadLocation: spdxDownloadLocation(report),
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  spdxLicense(report.Licenses.Project),
+		PrimaryPurpose:   "APPLICATION",
+		ExternalRefs:     []SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: rootPurl(report)}},
+	})
+	doc.Relationships = append(doc.Relationships, SPDXRelationship{
+		SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: rootID,
+	})
+
+	for i, c := range sbomComponents(report.Dependencies) {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, SPDXPackage{
+			Name:             c.dep.Name,
+			SPDXID:           id,
+			VersionInfo:      c.version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  spdxLicense(c.dep.License),
+			PrimaryPurpose:   "LIBRARY",
+			ExternalRefs:     []SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: c.purl}},
+		})
+
+		relationship := "DEPENDS_ON"
+		switch {
+		case c.dep.Scope == "dev" || c.dep.Scope == "test":
+			relationship = "DEV_DEPENDENCY_OF"
+		case c.dep.Scope == "optional":
+			relationship = "OPTIONAL_DEPENDENCY_OF"
+		}
+		if c.dep.Direct {
+			if relationship == "DEPENDS_ON" {
+				doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: rootID, RelationshipType: relationship, RelatedSPDXElement: id})
+			} else {
+				doc.Relationships = append(doc.Relationships, SPDXRelationship{SPDXElementID: id, RelationshipType: relationship, RelatedSPDXElement: rootID})
+			}
+		}
+	}
+
+	return doc
+}
+
+// sbomComponents dedupes the inventory by purl, since the same package is often
+// declared by several manifests of one repository
+func sbomComponents(inventory models.DependencyInventory) []sbomComponent {
+	var components []sbomComponent
+	index := make(map[string]int)
+
+	for _, dep := range inventory.Dependencies {
+		version := exactVersion(dep)
+		purl := packageURL(dep.Ecosystem, dep.Name, version)
+
+		if i, ok := index[purl]; ok {
+			// Prefer the direct declaration and keep any license we learned elsewhere
+			if dep.Direct && !components[i].dep.Direct {
+				components[i].dep.Direct = true
+				components[i].dep.Scope = dep.Scope
+			}
+			if components[i].dep.License == "" {
+				components[i].dep.License = dep.License
+			}
+			continue
+		}
+		index[purl] = len(components)
+		components = append(components, sbomComponent{dep: dep, version: version, purl: purl})
+	}
+	return components
+}
+
+// exactVersionPattern matches concrete versions like "1.2.3", "v0.30.0" or "2.0.0-rc.1+build"
+var exactVersionPattern = regexp.MustCompile(`^v?\d[\w.+-]*$`)
+
+// exactVersion returns the concrete version of a dependency, or "" when only a range is known
+func exactVersion(dep models.Dependency) string {
+	if dep.Resolved != "" {
+		return dep.Resolved
+	}
+	version := strings.TrimPrefix(strings.TrimPrefix(dep.Version, "=="), "=")
+	if exactVersionPattern.MatchString(version) {
+		return version
+	}
+	return ""
+}
+
+// purlTypes maps our ecosystem names to package-url types
+var purlTypes = map[string]string{
+	"go":       "golang",
+	"npm":      "npm",
+	"pypi":     "pypi",
+	"cargo":    "cargo",
+	"maven":    "maven",
+	"rubygems": "gem",
+	"github":   "github",
+}
+
+// packageURL builds a purl (https://github.com/package-url/purl-spec) for a dependency
+func packageURL(ecosystem, name, version string) string {
+	purlType, ok := purlTypes[ecosystem]
+	if !ok {
+		purlType = "generic"
+	}
+
+	switch ecosystem {
+	case "maven":
+		name = strings.Replace(name, ":", "/", 1)
+	case "pypi":
+		name = normalizeName(ecosystem, name)
+	}
+
+	// Escape each path segment, keeping the namespace separators
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "@", "%40")
+	}
+
+	purl := "pkg:" + purlType + "/" + strings.Join(segments, "/")
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	return purl
+}
+
+func rootPurl(report *models.AnalyticsReport) string {
+	return packageURL("github", report.RepoInfo.FullName, report.CommitSHA)
+}
+
+func spdxDownloadLocation(report *models.AnalyticsReport) string {
+	location := "git+https://github.com/" + report.RepoInfo.FullName + ".git"
+	if report.CommitSHA != "" {
+		location += "@" + report.CommitSHA
+	}
+	return location
+}
+
+// sbomVersionKey identifies this particular analysis: the commit SHA, or the
+// generation time for reports saved before we recorded SHAs
+func sbomVersionKey(report *models.AnalyticsReport) string {
+	if report.CommitSHA != "" {
+		return report.CommitSHA
+	}
+	return fmt.Sprintf("%d", report.GeneratedAt.Unix())
+}
+
+func cycloneDXLicenses(license string) []CycloneDXLicense {
+	if license == "" || license == "NOASSERTION" {
+		return nil
+	}
+	if strings.ContainsAny(license, " ()") {
+		return []CycloneDXLicense{{Expression: license}}
+	}
+	return []CycloneDXLicense{{License: &CycloneDXLicenseID{ID: license}}}
+}
+
+func spdxLicense(license string) string {
+	if license == "" {
+		return "NOASSERTION"
+	}
+	return license
+}
+
+// deterministicUUID derives a stable RFC 4122 (version 5 style) UUID, so
+// re-exporting the same analysis yields the same serial number
+func deterministicUUID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
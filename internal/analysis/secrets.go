@@ -0,0 +1,306 @@
+package analysis
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// secretRule is one detector. When MinEntropy is set, the captured secret (the last
+// submatch, or the whole match) must also look random enough to count.
+type secretRule struct {
+	ID          string
+	Description string
+	Severity    string
+	Pattern     *regexp.Regexp
+	MinEntropy  float64
+}
+
+var secretRules = []secretRule{
+	{"aws-access-key-id", "AWS access key ID", "high", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), 0},
+	{"aws-secret-access-key", "AWS secret access key", "critical", regexp.MustCompile(`(?i)aws.{0,20}(?:secret|private).{0,20}[:=]\s*['"]?([0-9a-zA-Z/+]{40})\b`), 3.5},
+	{"github-token", "GitHub token", "critical", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255})\b`), 0},
+	{"github-fine-grained-token", "GitHub fine-grained personal access token", "critical", regexp.MustCompile(`\b(github_pat_[A-Za-z0-9_]{60,255})\b`), 0},
+	{"google-api-key", "Google API key (Gemini, Maps, Firebase...)", "high", regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})\b`), 0},
+	{"slack-token", "Slack token", "high", regexp.MustCompile(`\b(xox[baprs]-[0-9A-Za-z-]{10,})\b`), 0},
+	{"slack-webhook", "Slack incoming webhook", "medium", regexp.MustCompile(`(https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]+)`), 0},
+	{"stripe-secret-key", "Stripe live secret key", "critical", regexp.MustCompile(`\b((?:sk|rk)_live_[0-9a-zA-Z]{20,})\b`), 0},
+	{"openai-api-key", "OpenAI API key", "high", regexp.MustCompile(`\b(sk-(?:proj-|svcacct-)?[A-Za-z0-9_\-]{20,}T3BlbkFJ[A-Za-z0-9_\-]{20,})\b`), 0},
+	{"anthropic-api-key", "Anthropic API key", "high", regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_\-]{32,})`), 0},
+	{"sendgrid-api-key", "SendGrid API key", "high", regexp.MustCompile(`\b(SG\.[A-Za-z0-9_\-]{22}\.[A-Za-z0-9_\-]{43})\b`), 0},
+	{"twilio-api-key", "Twilio API key", "medium", regexp.MustCompile(`\b(SK[0-9a-fA-F]{32})\b`), 3.0},
+	{"private-key", "Private key block", "critical", regexp.MustCompile(`(-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----)`), 0},
+	{"jwt", "JSON Web Token", "medium", regexp.MustCompile(`\b(eyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,})`), 0},
+	{"database-url-password", "Database connection string with password", "high", regexp.MustCompile(`(?i)\b(?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|amqp)://[^\s:@/'"]+:([^\s@/'"]{4,})@`), 0},
+	{"generic-secret", "Hard-coded secret assignment", "medium", regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|access[_-]?key|auth)[A-Za-z0-9_]*['"]?\s*[:=]\s*['"]?([A-Za-z0-9_\-+/=.]{16,})`), 3.5},
+}
+
+// placeholderHints mark values that are documentation, not credentials
+var placeholderHints = []string{"example", "xxxx", "your", "dummy", "changeme", "placeholder", "redacted", "test", "sample", "fake", "****", "<", "${", "{{", "process.env", "os.getenv"}
+
+// sensitiveFileNames are files whose mere presence in a repository is a finding
+var sensitiveFileNames = map[string]string{
+	"id_rsa":           "SSH private key",
+	"id_dsa":           "SSH private key",
+	"id_ecdsa":         "SSH private key",
+	"id_ed25519":       "SSH private key",
+	".npmrc":           "npm config (may hold registry tokens)",
+	".pypirc":          "PyPI config (may hold upload credentials)",
+	".netrc":           "netrc (stores plain-text credentials)",
+	"credentials.json": "Cloud credentials file",
+	".htpasswd":        "htpasswd password file",
+}
+
+// sensitiveExtensions are key/certificate containers
+var sensitiveExtensions = map[string]string{
+	".pem": "PEM key or certificate", ".key": "Private key file", ".p12": "PKCS#12 keystore",
+	".pfx": "PKCS#12 keystore", ".jks": "Java keystore", ".keystore": "Keystore",
+}
+
+// configExtensions are text formats where credentials usually end up
+var configExtensions = map[string]bool{
+	".yml": true, ".yaml": true, ".json": true, ".properties": true, ".ini": true,
+	".toml": true, ".cfg": true, ".conf": true, ".xml": true, ".tf": true, ".tfvars": true,
+}
+
+// maxSecretCandidates caps how many extra files we download just for secret scanning
+const maxSecretCandidates = 30
+
+// IsEnvFile reports whether a path is a real dotenv file (templates like .env.example don't count)
+func IsEnvFile(filePath string) bool {
+	base := strings.ToLower(path.Base(filePath))
+	if base != ".env" && !strings.HasPrefix(base, ".env.") && !strings.HasSuffix(base, ".env") {
+		return false
+	}
+	for _, template := range []string{"example", "sample", "template", "dist", "defaults"} {
+		if strings.Contains(base, template) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindSecretCandidates picks the files most likely to hold credentials:
+// dotenv files and key containers first, then shallow config files
+func FindSecretCandidates(paths []string) []string {
+	var primary, config []string
+	for _, p := range paths {
+		if isVendored(p) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(p))
+		_, sensitiveName := sensitiveFileNames[path.Base(p)]
+		switch {
+		case IsEnvFile(p) || sensitiveName || ext == ".pem" || ext == ".key":
+			primary = append(primary, p)
+		case configExtensions[ext] && !IsManifest(p) && !strings.HasSuffix(p, "lock.json"):
+			config = append(config, p)
+		}
+	}
+
+	sort.SliceStable(config, func(i, j int) bool {
+		return strings.Count(config[i], "/") < strings.Count(config[j], "/")
+	})
+
+	candidates := append(primary, config...)
+	if len(candidates) > maxSecretCandidates {
+		candidates = candidates[:maxSecretCandidates]
+	}
+	return candidates
+}
+
+// ScanTreeForSecrets flags sensitive files by name alone - committed .env files,
+// private keys and keystores - whether or not we download them
+func ScanTreeForSecrets(paths []string) []models.SecretFinding {
+	findings := []models.SecretFinding{}
+	for _, p := range paths {
+		if isVendored(p) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(p))
+		switch {
+		case IsEnvFile(p):
+			findings = append(findings, models.SecretFinding{Path: p, Rule: "dotenv-file", Description: "Committed environment file", Severity: "high"})
+		case sensitiveFileNames[path.Base(p)] != "":
+			findings = append(findings, models.SecretFinding{Path: p, Rule: "sensitive-file", Description: sensitiveFileNames[path.Base(p)], Severity: "medium"})
+		case sensitiveExtensions[ext] != "":
+			findings = append(findings, models.SecretFinding{Path: p, Rule: "sensitive-file", Description: sensitiveExtensions[ext], Severity: "medium"})
+		}
+	}
+	return findings
+}
+
+// ScanContentForSecrets runs every rule over one file, line by line
+func ScanContentForSecrets(filePath, content string) []models.SecretFinding {
+	var findings []models.SecretFinding
+	envFile := IsEnvFile(filePath)
+
+	for i, line := range strings.Split(content, "\n") {
+		findings = append(findings, scanLine(filePath, i+1, line, envFile)...)
+	}
+	return findings
+}
+
+// scanLine applies the rules to a single line. In dotenv files every high-entropy
+// value counts, not just the ones whose key looks like a secret.
+func scanLine(filePath string, lineNumber int, line string, envFile bool) []models.SecretFinding {
+	var findings []models.SecretFinding
+	if len(line) > 4096 {
+		return nil // minified bundles and data blobs
+	}
+
+	matchedAny := false
+	for _, rule := range secretRules {
+		for _, match := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+			secret := match[len(match)-1]
+			if rule.MinEntropy > 0 && (shannonEntropy(secret) < rule.MinEntropy || looksLikePlaceholder(secret)) {
+				continue
+			}
+			findings = append(findings, models.SecretFinding{
+				Path:        filePath,
+				Line:        lineNumber,
+				Rule:        rule.ID,
+				Description: rule.Description,
+				Severity:    rule.Severity,
+				Match:       redactSecret(secret),
+			})
+			matchedAny = true
+		}
+		if matchedAny && rule.ID != "generic-secret" {
+			break // the specific rule wins over the generic one on the same line
+		}
+	}
+
+	if envFile && !matchedAny {
+		if key, value, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "="); ok {
+			value = strings.Trim(strings.TrimSpace(value), `'"`)
+			if len(value) >= 12 && shannonEntropy(value) >= 3.5 && !looksLikePlaceholder(value) && !strings.HasPrefix(key, "#") {
+				findings = append(findings, models.SecretFinding{
+					Path:        filePath,
+					Line:        lineNumber,
+					Rule:        "dotenv-value",
+					Description: "High-entropy value for " + strings.TrimSpace(key) + " in a dotenv file",
+					Severity:    "high",
+					Match:       redactSecret(value),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// hunkHeaderPattern reads the new-file start line from "@@ -12,7 +14,8 @@"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanPatchForSecrets scans only the lines a commit added, reporting new-file line numbers
+func ScanPatchForSecrets(commitSHA, filePath, patch string) []models.SecretFinding {
+	var findings []models.SecretFinding
+	envFile := IsEnvFile(filePath)
+
+	newLine := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if match := hunkHeaderPattern.FindStringSubmatch(line); match != nil {
+			newLine, _ = strconv.Atoi(match[1])
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			for _, finding := range scanLine(filePath, newLine, line[1:], envFile) {
+				finding.Commit = commitSHA
+				findings = append(findings, finding)
+			}
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			// removed line, doesn't exist in the new file
+		default:
+			newLine++
+		}
+	}
+	return findings
+}
+
+// BuildSecretScan combines the tree, file content and (optional) history findings into one section
+func BuildSecretScan(paths []string, files map[string]string, history []models.SecretFinding, commitsScanned int) models.SecretScanReport {
+	scan := models.SecretScanReport{
+		Findings:       ScanTreeForSecrets(paths),
+		FilesScanned:   len(files),
+		CommitsScanned: commitsScanned,
+	}
+
+	for _, filePath := range sortedKeys(files) {
+		scan.Findings = append(scan.Findings, ScanContentForSecrets(filePath, files[filePath])...)
+	}
+	scan.Findings = append(scan.Findings, history...)
+
+	// The same leaked key usually shows up in several commits - keep one per location. History
+	// comes newest first, so the last commit seen at a location is the earliest one.
+	seen := make(map[string]int)
+	deduped := scan.Findings[:0]
+	for _, finding := range scan.Findings {
+		key := finding.Path + "|" + strconv.Itoa(finding.Line) + "|" + finding.Rule + "|" + finding.Match
+		if index, ok := seen[key]; ok {
+			if finding.Commit != "" {
+				deduped[index].Commit = finding.Commit
+			}
+			continue
+		}
+		seen[key] = len(deduped)
+		deduped = append(deduped, finding)
+	}
+	scan.Findings = deduped
+
+	for _, finding := range scan.Findings {
+		if finding.Severity == "critical" || finding.Severity == "high" {
+			scan.HighSeverityCount++
+		}
+	}
+	return scan
+}
+
+// shannonEntropy returns the bits of entropy per character of s
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	length := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func looksLikePlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, hint := range placeholderHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecret keeps just enough of a secret to recognize it: "AKIA************XY"
+func redactSecret(secret string) string {
+	if strings.HasPrefix(secret, "-----BEGIN") {
+		return secret // the PEM header isn't sensitive, the body is never captured
+	}
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	stars := len(secret) - 6
+	if stars > 16 {
+		stars = 16
+	}
+	return secret[:4] + strings.Repeat("*", stars) + secret[len(secret)-2:]
+}
@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// maxUnverifiedListed caps the unverified-on-protected list; the count stays exact
+const maxUnverifiedListed = 100
+
+// BuildSignatureReport computes signed and verified shares overall, per contributor and per
+// month. protected maps each protected branch to the commits of it we know about; the ones
+// without a verified signature are flagged.
+func BuildSignatureReport(commits []models.CommitActivity, protected map[string][]models.CommitActivity) models.SignatureReport {
+	report := models.SignatureReport{
+		SignatureTypes:        map[string]int{},
+		Reasons:               map[string]int{},
+		Contributors:          []models.ContributorSignatures{},
+		Timeline:              []models.SignatureMonth{},
+		ProtectedBranches:     sortedKeys(protected),
+		UnverifiedOnProtected: []models.UnverifiedCommit{},
+	}
+
+	contributors := make(map[string]*models.ContributorSignatures)
+	months := make(map[string]*models.SignatureMonth)
+	for _, commit := range commits {
+		report.Commits++
+		signed := commit.Signature != ""
+
+		month := commit.Date.UTC().Format("2006-01")
+		if months[month] == nil {
+			months[month] = &models.SignatureMonth{Month: month}
+		}
+		months[month].Commits++
+
+		var contributor *models.ContributorSignatures
+		if commit.Author != "" {
+			if contributors[commit.Author] == nil {
+				contributors[commit.Author] = &models.ContributorSignatures{Author: commit.Author}
+			}
+			contributor = contributors[commit.Author]
+			contributor.Commits++
+		}
+
+		if signed {
+			report.Signed++
+			report.SignatureTypes[commit.Signature]++
+			months[month].Signed++
+			if contributor != nil {
+				contributor.Signed++
+			}
+		}
+		if commit.Verified {
+			report.Verified++
+			months[month].Verified++
+			if contributor != nil {
+				contributor.Verified++
+			}
+		} else {
+			report.Reasons[firstNonEmpty(commit.VerificationReason, "unsigned")]++
+		}
+	}
+	report.SignedRate = ratio(report.Signed, report.Commits)
+	report.VerifiedRate = ratio(report.Verified, report.Commits)
+
+	for _, contributor := range contributors {
+		contributor.VerifiedRate = ratio(contributor.Verified, contributor.Commits)
+		report.Contributors = append(report.Contributors, *contributor)
+	}
+	sort.Slice(report.Contributors, func(i, j int) bool {
+		if report.Contributors[i].Commits != report.Contributors[j].Commits {
+			return report.Contributors[i].Commits > report.Contributors[j].Commits
+		}
+		return report.Contributors[i].Author < report.Contributors[j].Author
+	})
+
+	for _, month := range sortedKeys(months) {
+		report.Timeline = append(report.Timeline, *months[month])
+	}
+
+	var unverified []models.UnverifiedCommit
+	for _, branch := range report.ProtectedBranches {
+		for _, commit := range protected[branch] {
+			if !commit.Verified {
+				unverified = append(unverified, models.UnverifiedCommit{
+					SHA:    commit.SHA,
+					Author: commit.Author,
+					Branch: branch,
+					Reason: firstNonEmpty(commit.VerificationReason, "unsigned"),
+					Date:   commit.Date,
+				})
+			}
+		}
+	}
+	sort.SliceStable(unverified, func(i, j int) bool {
+		return unverified[i].Date.After(unverified[j].Date)
+	})
+	report.UnverifiedOnProtectedCount = len(unverified)
+	if len(unverified) > maxUnverifiedListed {
+		unverified = unverified[:maxUnverifiedListed]
+	}
+	report.UnverifiedOnProtected = append(report.UnverifiedOnProtected, unverified...)
+	return report
+}
@@ -0,0 +1,263 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// testDirs are folders whose source files are tests by convention
+var testDirs = []string{"test/", "tests/", "spec/", "__tests__/", "testing/", "src/test/", "e2e/", "cypress/"}
+
+// testFilePatterns are the per-language file naming conventions for tests
+var testFilePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`_test\.(go|py|rb|dart|exs)$`),                 // Go, pytest, minitest, Dart, ExUnit
+	regexp.MustCompile(`\.(test|spec)\.(js|jsx|ts|tsx|mjs|cjs|vue)$`), // Jest, Vitest, Mocha, Jasmine
+	regexp.MustCompile(`^test_.*\.py$`),                               // pytest, unittest
+	regexp.MustCompile(`_spec\.rb$`),                                  // RSpec
+	regexp.MustCompile(`(Test|Tests|IT|Spec)\.(java|kt|scala|cs|swift|php)$`),
+	regexp.MustCompile(`^conftest\.py$`),
+}
+
+// testAffixes are stripped from test file names to find the file they cover
+var testAffixes = regexp.MustCompile(`^test_|_test$|_spec$|\.test$|\.spec$|(Tests?|IT|Spec)$`)
+
+// testFrameworkDeps maps dependency names to the frameworks they indicate
+var testFrameworkDeps = map[string]string{
+	"jest": "Jest", "vitest": "Vitest", "mocha": "Mocha", "jasmine": "Jasmine", "ava": "AVA",
+	"@playwright/test": "Playwright", "cypress": "Cypress", "karma": "Karma", "@testing-library/react": "Testing Library",
+	"pytest": "pytest", "nose2": "nose2", "hypothesis": "Hypothesis", "tox": "tox",
+	"rspec": "RSpec", "rspec-rails": "RSpec", "minitest": "Minitest", "capybara": "Capybara",
+	"junit:junit": "JUnit 4", "org.junit.jupiter:junit-jupiter": "JUnit 5", "org.junit.jupiter:junit-jupiter-api": "JUnit 5",
+	"org.testng:testng": "TestNG", "org.mockito:mockito-core": "Mockito",
+	"github.com/stretchr/testify": "testify", "github.com/onsi/ginkgo/v2": "Ginkgo", "github.com/onsi/gomega": "Gomega",
+	"proptest": "proptest", "rstest": "rstest", "criterion": "Criterion",
+}
+
+// testFrameworkFiles maps config file names to the frameworks they configure
+var testFrameworkFiles = map[string]string{
+	"jest.config.js": "Jest", "jest.config.ts": "Jest", "jest.config.mjs": "Jest",
+	"vitest.config.ts": "Vitest", "vitest.config.js": "Vitest", "vitest.config.mts": "Vitest",
+	".mocharc.json": "Mocha", ".mocharc.yml": "Mocha", ".mocharc.js": "Mocha",
+	"karma.conf.js": "Karma", "playwright.config.ts": "Playwright", "playwright.config.js": "Playwright",
+	"cypress.config.ts": "Cypress", "cypress.config.js": "Cypress", "cypress.json": "Cypress",
+	"pytest.ini": "pytest", "conftest.py": "pytest", "tox.ini": "tox", ".rspec": "RSpec",
+	"phpunit.xml": "PHPUnit", "phpunit.xml.dist": "PHPUnit",
+}
+
+// maxTestDirectories caps the per-directory breakdown so monorepos don't bloat the report
+const maxTestDirectories = 100
+
+// maxUntestedPackages caps the untested list, biggest packages first
+const maxUntestedPackages = 50
+
+// defaultBytesPerLine is used to estimate line counts when no file contents were fetched
+const defaultBytesPerLine = 32
+
+// IsTestFile reports whether a source path is a test by its language's conventions
+func IsTestFile(filePath string) bool {
+	if !IsSourceFile(filePath) {
+		return false
+	}
+	base := path.Base(filePath)
+	for _, pattern := range testFilePatterns {
+		if pattern.MatchString(base) {
+			return true
+		}
+	}
+	for _, dir := range testDirs {
+		if strings.HasPrefix(filePath, dir) || strings.Contains(filePath, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildTestReport splits the tree into test and production code and computes the
+// test-to-code line ratio overall and per directory. sizes are blob sizes from the tree;
+// files are whatever contents were already fetched - those are counted exactly and
+// calibrate the bytes-per-line estimate for everything else.
+func BuildTestReport(sizes map[string]int, files map[string]string, deps models.DependencyInventory) models.TestReport {
+	report := models.TestReport{
+		Frameworks:       []string{},
+		Directories:      []models.TestDirectory{},
+		UntestedPackages: []string{},
+	}
+
+	var sourcePaths, testPaths []string
+	for _, p := range sortedKeys(sizes) {
+		switch {
+		case isToolingConfig(p):
+			continue
+		case IsTestFile(p):
+			testPaths = append(testPaths, p)
+		case IsSourceFile(p):
+			sourcePaths = append(sourcePaths, p)
+		}
+	}
+	report.SourceFiles = len(sourcePaths)
+	report.TestFiles = len(testPaths)
+
+	bytesPerLine := estimateBytesPerLine(sizes, files)
+	lines := func(p string) int {
+		if content, ok := files[p]; ok {
+			return strings.Count(content, "\n") + 1
+		}
+		report.LinesEstimated = true
+		if sizes[p] == 0 {
+			return 0
+		}
+		return sizes[p]/bytesPerLine + 1
+	}
+
+	// Each test is credited to the directory of the code it covers: its own directory
+	// for co-located tests, or the directory of a same-named source file elsewhere
+	sourceDirsByName := make(map[string][]string)
+	directories := make(map[string]*models.TestDirectory)
+	dirFor := func(dir string) *models.TestDirectory {
+		if directories[dir] == nil {
+			directories[dir] = &models.TestDirectory{Path: dir}
+		}
+		return directories[dir]
+	}
+
+	for _, p := range sourcePaths {
+		dir := path.Dir(p)
+		entry := dirFor(dir)
+		entry.SourceFiles++
+		entry.CodeLines += lines(p)
+		name := subjectName(p)
+		sourceDirsByName[name] = append(sourceDirsByName[name], dir)
+	}
+
+	for _, p := range testPaths {
+		dir := strings.Replace(path.Dir(p), "/__tests__", "", 1)
+		if _, colocated := directories[dir]; !colocated {
+			if candidates := sourceDirsByName[subjectName(p)]; len(candidates) > 0 {
+				dir = closestDir(p, candidates)
+			}
+		}
+		entry := dirFor(dir)
+		entry.TestFiles++
+		entry.TestLines += lines(p)
+	}
+
+	for _, entry := range directories {
+		report.CodeLines += entry.CodeLines
+		report.TestLines += entry.TestLines
+		entry.Ratio = ratio(entry.TestLines, entry.CodeLines)
+	}
+	report.Ratio = ratio(report.TestLines, report.CodeLines)
+
+	// Packages (directories of production code) nobody tests, biggest first
+	var ordered []*models.TestDirectory
+	for _, entry := range directories {
+		ordered = append(ordered, entry)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].CodeLines != ordered[j].CodeLines {
+			return ordered[i].CodeLines > ordered[j].CodeLines
+		}
+		return ordered[i].Path < ordered[j].Path
+	})
+	for _, entry := range ordered {
+		if entry.SourceFiles > 0 && entry.TestFiles == 0 && len(report.UntestedPackages) < maxUntestedPackages {
+			report.UntestedPackages = append(report.UntestedPackages, entry.Path)
+		}
+		if len(report.Directories) < maxTestDirectories {
+			report.Directories = append(report.Directories, *entry)
+		}
+	}
+
+	report.Frameworks = detectTestFrameworks(sizes, testPaths, deps)
+	return report
+}
+
+// isToolingConfig reports whether a "source" file only configures build or test tools
+// (jest.config.js, conftest.py...) and so shouldn't count as production code
+func isToolingConfig(filePath string) bool {
+	base := path.Base(filePath)
+	if _, ok := testFrameworkFiles[base]; ok {
+		return base != "conftest.py" // pytest fixtures are test code
+	}
+	return strings.Contains(base, ".config.") || strings.HasPrefix(base, ".")
+}
+
+// estimateBytesPerLine averages the source files we have contents for
+func estimateBytesPerLine(sizes map[string]int, files map[string]string) int {
+	totalBytes, totalLines := 0, 0
+	for p, content := range files {
+		if IsSourceFile(p) && sizes[p] > 0 {
+			totalBytes += len(content)
+			totalLines += strings.Count(content, "\n") + 1
+		}
+	}
+	if totalLines == 0 || totalBytes/totalLines == 0 {
+		return defaultBytesPerLine
+	}
+	return totalBytes / totalLines
+}
+
+// subjectName reduces "tests/test_parser.py" and "src/Parser.test.ts" to "parser"
+func subjectName(filePath string) string {
+	name := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	if stripped := testAffixes.ReplaceAllString(name, ""); stripped != "" {
+		name = stripped
+	}
+	return strings.ToLower(name)
+}
+
+// closestDir picks the candidate sharing the longest directory suffix with the test,
+// so tests/utils/format_test.py maps to src/utils rather than scripts/
+func closestDir(testPath string, candidates []string) string {
+	testParts := strings.Split(path.Dir(testPath), "/")
+	best, bestScore := candidates[0], -1
+	for _, candidate := range candidates {
+		parts := strings.Split(candidate, "/")
+		score := 0
+		for i, j := len(parts)-1, len(testParts)-1; i >= 0 && j >= 0 && parts[i] == testParts[j]; i, j = i-1, j-1 {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+func detectTestFrameworks(sizes map[string]int, testPaths []string, deps models.DependencyInventory) []string {
+	frameworks := make(map[string]bool)
+	for _, dep := range deps.Dependencies {
+		if framework, ok := testFrameworkDeps[dep.Name]; ok {
+			frameworks[framework] = true
+		}
+	}
+	for p := range sizes {
+		if framework, ok := testFrameworkFiles[path.Base(p)]; ok && !isVendored(p) {
+			frameworks[framework] = true
+		}
+	}
+
+	// Languages with a test runner in the toolchain need no dependency to show up
+	for _, p := range testPaths {
+		switch path.Ext(p) {
+		case ".go":
+			frameworks["go test"] = true
+		case ".rs":
+			frameworks["cargo test"] = true
+		case ".exs":
+			frameworks["ExUnit"] = true
+		}
+	}
+	return sortedKeys(frameworks)
+}
+
+func ratio(test, code int) float64 {
+	if code == 0 {
+		return 0
+	}
+	return float64(int(float64(test)/float64(code)*100+0.5)) / 100
+}
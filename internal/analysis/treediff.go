@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// languageExtensions maps file extensions to the language names GitHub's linguist uses,
+// so deltas line up with the languages section of a report
+var languageExtensions = map[string]string{
+	".go": "Go", ".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".py": "Python", ".rb": "Ruby", ".rs": "Rust",
+	".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala", ".swift": "Swift",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++",
+	".cs": "C#", ".php": "PHP", ".vue": "Vue", ".svelte": "Svelte", ".dart": "Dart",
+	".ex": "Elixir", ".exs": "Elixir", ".sh": "Shell", ".bash": "Shell", ".lua": "Lua",
+	".html": "HTML", ".css": "CSS", ".scss": "SCSS", ".sql": "SQL", ".r": "R", ".m": "Objective-C",
+	".hs": "Haskell", ".clj": "Clojure", ".erl": "Erlang", ".zig": "Zig", ".tf": "HCL",
+	".ipynb": "Jupyter Notebook", ".dockerfile": "Dockerfile",
+}
+
+// LanguageOf returns the language of a file by its extension, or "" for non-code files
+func LanguageOf(filePath string) string {
+	if strings.HasPrefix(path.Base(filePath), "Dockerfile") {
+		return "Dockerfile"
+	}
+	return languageExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// LanguageBytes totals blob sizes per language, the same shape as GitHub's languages API
+func LanguageBytes(sizes map[string]int) map[string]int {
+	languages := make(map[string]int)
+	for p, size := range sizes {
+		if language := LanguageOf(p); language != "" && !isVendored(p) {
+			languages[language] += size
+		}
+	}
+	return languages
+}
+
+// CompareTrees computes file count, size and per-language deltas between two trees,
+// given as path -> blob size maps. Vendored files are left out, as linguist does.
+func CompareTrees(base, head map[string]int) models.TreeDelta {
+	delta := models.TreeDelta{Languages: []models.LanguageDelta{}}
+	languages := make(map[string]*models.LanguageDelta)
+	entry := func(language string) *models.LanguageDelta {
+		if languages[language] == nil {
+			languages[language] = &models.LanguageDelta{Language: language}
+		}
+		return languages[language]
+	}
+
+	for p, size := range base {
+		delta.BaseFiles++
+		delta.BaseBytes += size
+		if language := LanguageOf(p); language != "" && !isVendored(p) {
+			entry(language).BaseFiles++
+			entry(language).BaseBytes += size
+		}
+	}
+	for p, size := range head {
+		delta.HeadFiles++
+		delta.HeadBytes += size
+		if language := LanguageOf(p); language != "" && !isVendored(p) {
+			entry(language).HeadFiles++
+			entry(language).HeadBytes += size
+		}
+	}
+
+	for _, language := range languages {
+		language.Delta = language.HeadBytes - language.BaseBytes
+		if language.Delta != 0 || language.BaseFiles != language.HeadFiles {
+			delta.Languages = append(delta.Languages, *language)
+		}
+	}
+	sort.Slice(delta.Languages, func(i, j int) bool {
+		a, b := abs(delta.Languages[i].Delta), abs(delta.Languages[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return delta.Languages[i].Language < delta.Languages[j].Language
+	})
+	return delta
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
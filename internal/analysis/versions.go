@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"strconv"
+	"strings"
+)
+
+// postReleaseTags rank above a bare release ("1.0.post1" > "1.0"); any other tag ranks below it
+var postReleaseTags = map[string]bool{"post": true, "p": true, "patch": true, "sp": true, "final": true, "ga": true, "release": true}
+
+// compareVersions orders two versions of the same ecosystem, returning -1, 0 or 1.
+// One tokenizer covers SemVer (Go, npm, Cargo), PEP 440 and Maven/RubyGems well enough
+// for advisory ranges: numbers compare numerically, pre-release tags sort before the release.
+func compareVersions(ecosystem, a, b string) int {
+	a, b = cleanVersion(ecosystem, a), cleanVersion(ecosystem, b)
+	if a == b {
+		return 0
+	}
+
+	ta, tb := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			return -tailSign(tb[i:])
+		case i >= len(tb):
+			return tailSign(ta[i:])
+		}
+		if c := compareToken(ta[i], tb[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// cleanVersion strips prefixes and build metadata that never affect ordering
+func cleanVersion(ecosystem, version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	version = strings.TrimLeft(version, "=v")
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i] // SemVer build metadata, Go +incompatible
+	}
+	if ecosystem == "pypi" {
+		if i := strings.Index(version, "!"); i >= 0 {
+			version = version[i+1:] // PEP 440 epochs are vanishingly rare in advisories
+		}
+	}
+	return version
+}
+
+// versionTokens splits "1.2.0-rc.1" into ["1" "2" "0" "rc" "1"], also breaking on digit/letter changes
+func versionTokens(version string) []string {
+	var tokens []string
+	current := ""
+	flush := func() {
+		if current != "" {
+			tokens = append(tokens, current)
+			current = ""
+		}
+	}
+	for _, r := range version {
+		switch {
+		case r == '.' || r == '-' || r == '_' || r == '~':
+			flush()
+		case current != "" && isDigit(rune(current[len(current)-1])) != isDigit(r):
+			flush()
+			current = string(r)
+		default:
+			current += string(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// tailSign decides what the leftover tokens of the longer version mean:
+// "1.0.0" vs "1.0" is equal, "1.0.1" is newer, "1.0-rc1" is older
+func tailSign(tail []string) int {
+	for _, token := range tail {
+		if n, err := strconv.Atoi(token); err == nil {
+			if n > 0 {
+				return 1
+			}
+			continue
+		}
+		// Unknown SemVer identifiers ("next", "canary") are pre-releases too
+		if postReleaseTags[token] {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+func compareToken(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		// A number beats a pre-release tag ("1.0.1" > "1.0.rc1") but loses to a post tag
+		if postReleaseTags[b] {
+			return -1
+		}
+		return 1
+	case errB == nil:
+		if postReleaseTags[a] {
+			return 1
+		}
+		return -1
+	}
+
+	rankA, rankB := tagRank(a), tagRank(b)
+	if rankA != rankB {
+		return compareInts(rankA, rankB)
+	}
+	return strings.Compare(a, b)
+}
+
+// tagRank orders textual qualifiers: dev < alpha < beta < rc < (release) < post
+func tagRank(tag string) int {
+	switch tag {
+	case "dev", "snapshot":
+		return 0
+	case "a", "alpha":
+		return 1
+	case "b", "beta":
+		return 2
+	case "m", "milestone":
+		return 3
+	case "c", "rc", "pre", "preview":
+		return 4
+	}
+	if postReleaseTags[tag] {
+		return 6
+	}
+	return 5
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,407 @@
+package analysis
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// osvEcosystems maps our ecosystem names to the ones used in OSV records
+var osvEcosystems = map[string]string{
+	"go":       "Go",
+	"npm":      "npm",
+	"pypi":     "PyPI",
+	"cargo":    "crates.io",
+	"maven":    "Maven",
+	"rubygems": "RubyGems",
+}
+
+// osvRecord is the subset of the OSV schema (https://ossf.github.io/osv-schema/) we use
+type osvRecord struct {
+	ID        string   `json:"id"`
+	Summary   string   `json:"summary"`
+	Aliases   []string `json:"aliases"`
+	Withdrawn string   `json:"withdrawn"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected         []osvAffected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string `json:"type"`
+		Events []struct {
+			Introduced   string `json:"introduced,omitempty"`
+			Fixed        string `json:"fixed,omitempty"`
+			LastAffected string `json:"last_affected,omitempty"`
+			Limit        string `json:"limit,omitempty"`
+		} `json:"events"`
+	} `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// osvEntry is one affected package of one advisory, indexed for lookup
+type osvEntry struct {
+	record   *osvRecord
+	affected osvAffected
+}
+
+// VulnDatabase is an in-memory index of an OSV snapshot, keyed by ecosystem and package name
+type VulnDatabase struct {
+	Source     string
+	Advisories int
+	index      map[string]map[string][]osvEntry
+}
+
+// LoadVulnDatabase reads an OSV snapshot from a directory of JSON records (zip files
+// inside it, like the per-ecosystem all.zip exports, are read too) or from a single zip.
+// It never touches the network.
+func LoadVulnDatabase(location string) (*VulnDatabase, error) {
+	db := &VulnDatabase{
+		Source: location,
+		index:  make(map[string]map[string][]osvEntry),
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("could not open OSV database: %w", err)
+	}
+
+	if !info.IsDir() {
+		if err := db.loadZip(location); err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	err = filepath.WalkDir(location, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			db.add(data)
+		case ".zip":
+			return db.loadZip(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load OSV database: %w", err)
+	}
+
+	return db, nil
+}
+
+func (db *VulnDatabase) loadZip(path string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("could not open OSV archive %s: %w", path, err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".json") {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("could not read %s in %s: %w", file.Name, path, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("could not read %s in %s: %w", file.Name, path, err)
+		}
+		db.add(data)
+	}
+	return nil
+}
+
+// add indexes one OSV record; malformed and withdrawn records are skipped
+func (db *VulnDatabase) add(data []byte) {
+	var record osvRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.ID == "" || record.Withdrawn != "" {
+		return
+	}
+	db.Advisories++
+
+	for _, affected := range record.Affected {
+		ecosystem := affected.Package.Ecosystem
+		// "Debian:11", "Alpine:v3.18"... we only care about the base ecosystem
+		if i := strings.Index(ecosystem, ":"); i >= 0 {
+			ecosystem = ecosystem[:i]
+		}
+		if db.index[ecosystem] == nil {
+			db.index[ecosystem] = make(map[string][]osvEntry)
+		}
+		name := osvPackageKey(ecosystem, affected.Package.Name)
+		db.index[ecosystem][name] = append(db.index[ecosystem][name], osvEntry{record: &record, affected: affected})
+	}
+}
+
+// osvPackageKey normalizes package names the way each ecosystem compares them
+func osvPackageKey(osvEcosystem, name string) string {
+	switch osvEcosystem {
+	case "PyPI":
+		return normalizeName("pypi", name)
+	case "crates.io":
+		return normalizeName("cargo", name)
+	}
+	return name
+}
+
+// MatchVulnerabilities checks every dependency with a known exact version against the
+// snapshot. db may be nil, in which case the section only records that no database was loaded.
+func MatchVulnerabilities(db *VulnDatabase, inventory models.DependencyInventory) models.VulnerabilityReport {
+	report := models.VulnerabilityReport{Findings: []models.Vulnerability{}}
+	if db == nil {
+		return report
+	}
+	report.Database = db.Source
+
+	seen := make(map[string]bool)
+	for _, dep := range inventory.Dependencies {
+		ecosystem, ok := osvEcosystems[dep.Ecosystem]
+		if !ok {
+			continue
+		}
+		version := exactVersion(dep)
+		if version == "" {
+			report.Unresolved++
+			continue
+		}
+		report.Scanned++
+
+		for _, entry := range db.index[ecosystem][osvPackageKey(ecosystem, dep.Name)] {
+			affected, fixed := entry.affects(dep.Ecosystem, version)
+			if !affected {
+				continue
+			}
+
+			key := dep.Ecosystem + "|" + dep.Name + "|" + version + "|" + entry.record.ID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			severity, score := osvSeverity(entry.record)
+			report.Findings = append(report.Findings, models.Vulnerability{
+				Package:          dep.Name,
+				Ecosystem:        dep.Ecosystem,
+				InstalledVersion: version,
+				AdvisoryID:       entry.record.ID,
+				Aliases:          entry.record.Aliases,
+				Summary:          entry.record.Summary,
+				Severity:         severity,
+				Score:            score,
+				FixedVersion:     fixed,
+				Direct:           dep.Direct,
+				Source:           dep.Source,
+			})
+		}
+	}
+
+	// Worst first, so the top of the list is what to fix first
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return SeverityRank(report.Findings[i].Severity) > SeverityRank(report.Findings[j].Severity)
+	})
+	for _, finding := range report.Findings {
+		if SeverityRank(finding.Severity) >= SeverityRank("HIGH") {
+			report.HighSeverityCount++
+		}
+	}
+	return report
+}
+
+// affects reports whether version falls inside this entry, and the nearest fixed version
+func (entry osvEntry) affects(ecosystem, version string) (bool, string) {
+	for _, listed := range entry.affected.Versions {
+		if compareVersions(ecosystem, listed, version) == 0 {
+			return true, entry.fixedAfter(ecosystem, version)
+		}
+	}
+
+	for _, r := range entry.affected.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue // GIT ranges need commit graphs we don't have
+		}
+
+		affected := false
+		for _, event := range r.Events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || compareVersions(ecosystem, version, event.Introduced) >= 0 {
+					affected = true
+				}
+			case event.Fixed != "":
+				if compareVersions(ecosystem, version, event.Fixed) >= 0 {
+					affected = false
+				}
+			case event.LastAffected != "":
+				if compareVersions(ecosystem, version, event.LastAffected) > 0 {
+					affected = false
+				}
+			case event.Limit != "":
+				if compareVersions(ecosystem, version, event.Limit) >= 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true, entry.fixedAfter(ecosystem, version)
+		}
+	}
+	return false, ""
+}
+
+// fixedAfter returns the lowest fixed version above the installed one
+func (entry osvEntry) fixedAfter(ecosystem, version string) string {
+	best := ""
+	for _, r := range entry.affected.Ranges {
+		for _, event := range r.Events {
+			if event.Fixed == "" || r.Type == "GIT" || compareVersions(ecosystem, event.Fixed, version) <= 0 {
+				continue
+			}
+			if best == "" || compareVersions(ecosystem, event.Fixed, best) < 0 {
+				best = event.Fixed
+			}
+		}
+	}
+	return best
+}
+
+// osvSeverity prefers the advisory's own label (GHSA records carry one) and falls
+// back to the CVSS v3 base score
+func osvSeverity(record *osvRecord) (string, float64) {
+	score := 0.0
+	for _, s := range record.Severity {
+		if strings.HasPrefix(s.Type, "CVSS_V3") {
+			if parsed, ok := cvss3BaseScore(s.Score); ok {
+				score = parsed
+			}
+		}
+	}
+
+	label := strings.ToUpper(record.DatabaseSpecific.Severity)
+	if label == "MODERATE" {
+		label = "MEDIUM"
+	}
+	if SeverityRank(label) > 0 {
+		return label, score
+	}
+
+	switch {
+	case score >= 9.0:
+		return "CRITICAL", score
+	case score >= 7.0:
+		return "HIGH", score
+	case score >= 4.0:
+		return "MEDIUM", score
+	case score > 0:
+		return "LOW", score
+	}
+	return "UNKNOWN", score
+}
+
+// SeverityRank orders advisory severities; anything unrecognized ranks lowest
+func SeverityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MEDIUM", "MODERATE":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+// cvss3BaseScore computes the CVSS v3.x base score from a vector such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func cvss3BaseScore(vector string) (float64, bool) {
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/") {
+		if key, value, ok := strings.Cut(part, ":"); ok {
+			metrics[key] = value
+		}
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	values := make(map[string]float64)
+	for metric, table := range weights {
+		weight, ok := table[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = weight
+	}
+
+	changed := metrics["S"] == "C"
+	if metrics["S"] != "U" && !changed {
+		return 0, false
+	}
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	pr, ok := privileges[metrics["PR"]]
+	if !ok {
+		return 0, false
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+	if changed {
+		return cvssRoundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return cvssRoundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// cvssRoundUp is the spec's "Roundup": smallest one-decimal number >= x, float-safe
+func cvssRoundUp(x float64) float64 {
+	scaled := int(math.Round(x * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return float64(scaled/10000+1) / 10
+}
@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// minCommitsForPattern is how many commits a contributor needs before their hours mean anything
+const minCommitsForPattern = 5
+
+// maxTimezoneContributors caps the per-contributor breakdown to the most active people
+const maxTimezoneContributors = 25
+
+// coreWindowHours is the length of the "usual working day" window we look for
+const coreWindowHours = 8
+
+// typicalCoreMidpoint is the local hour most people's working day is centered on;
+// it's used to guess a timezone when only UTC times are known
+const typicalCoreMidpoint = 14
+
+// BuildWorkingHours computes per-contributor working-hour distributions, their likely
+// home timezone and the UTC windows in which contributors' core hours overlap
+func BuildWorkingHours(commits []models.CommitActivity) models.WorkingHoursReport {
+	report := models.WorkingHoursReport{
+		Contributors:   []models.ContributorHours{},
+		OverlapWindows: []models.OverlapWindow{},
+	}
+
+	byAuthor := make(map[string][]models.CommitActivity)
+	for _, commit := range commits {
+		if commit.OffsetKnown {
+			report.OffsetsKnown++
+		}
+		if commit.Author != "" {
+			byAuthor[commit.Author] = append(byAuthor[commit.Author], commit)
+		}
+	}
+
+	for author, authored := range byAuthor {
+		if len(authored) >= minCommitsForPattern {
+			report.Contributors = append(report.Contributors, contributorHours(author, authored))
+		}
+	}
+	sort.Slice(report.Contributors, func(i, j int) bool {
+		if report.Contributors[i].Commits != report.Contributors[j].Commits {
+			return report.Contributors[i].Commits > report.Contributors[j].Commits
+		}
+		return report.Contributors[i].Author < report.Contributors[j].Author
+	})
+	if len(report.Contributors) > maxTimezoneContributors {
+		report.Contributors = report.Contributors[:maxTimezoneContributors]
+	}
+
+	report.OverlapWindows = overlapWindows(report.Contributors)
+	return report
+}
+
+func contributorHours(author string, commits []models.CommitActivity) models.ContributorHours {
+	hours := models.ContributorHours{Author: author, Commits: len(commits)}
+
+	// The most common offset is the likely home timezone (travel and DST make the rest)
+	offsets := make(map[int]int)
+	for _, commit := range commits {
+		if commit.OffsetKnown {
+			offsets[commit.UTCOffset]++
+		}
+	}
+	known := 0
+	for offset, count := range offsets {
+		if count > offsets[hours.UTCOffset] || count == offsets[hours.UTCOffset] && offset < hours.UTCOffset {
+			hours.UTCOffset = offset
+		}
+		known += count
+	}
+
+	afterHours, weekend := 0, 0
+	for _, commit := range commits {
+		local := commit.Date.In(time.FixedZone("", commit.UTCOffset*60))
+		hours.Hours[local.Hour()]++
+		hours.Weekdays[local.Weekday()]++
+		if local.Hour() < 9 || local.Hour() >= 18 {
+			afterHours++
+		}
+		if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+			weekend++
+		}
+	}
+	hours.AfterHoursRate = ratio(afterHours, len(commits))
+	hours.WeekendRate = ratio(weekend, len(commits))
+
+	coreStart := busiestWindow(hours.Hours)
+	if known == 0 {
+		// Only UTC is known: assume the busiest window is a normal working day and
+		// infer the offset that would center it on the afternoon
+		hours.UTCOffset = wrapOffset(typicalCoreMidpoint-(coreStart+coreWindowHours/2)) * 60
+		hours.TimezoneInferred = true
+	}
+	hours.Timezone = formatUTCOffset(hours.UTCOffset)
+
+	// Hours were bucketed in each commit's own offset, so convert with the home offset
+	shift := (hours.UTCOffset + 30*sign(hours.UTCOffset)) / 60
+	if known == 0 {
+		shift = 0 // the histogram is already in UTC
+	}
+	hours.CoreStartUTC = mod24(coreStart - shift)
+	hours.CoreEndUTC = mod24(hours.CoreStartUTC + coreWindowHours)
+	return hours
+}
+
+// busiestWindow returns the start hour of the coreWindowHours-long (wrapping) window with
+// the most commits. A short burst fits several windows equally well; take the middle one.
+func busiestWindow(histogram [24]int) int {
+	var bestStarts []int
+	bestCount := -1
+	for start := 0; start < 24; start++ {
+		count := 0
+		for h := 0; h < coreWindowHours; h++ {
+			count += histogram[mod24(start+h)]
+		}
+		switch {
+		case count > bestCount:
+			bestStarts, bestCount = []int{start}, count
+		case count == bestCount:
+			bestStarts = append(bestStarts, start)
+		}
+	}
+	return bestStarts[len(bestStarts)/2]
+}
+
+// overlapWindows splits the UTC day into runs where the same two or more contributors
+// are inside their core hours, most crowded windows first
+func overlapWindows(contributors []models.ContributorHours) []models.OverlapWindow {
+	var present [24][]string
+	for _, c := range contributors {
+		for h := 0; h < coreWindowHours; h++ {
+			utc := mod24(c.CoreStartUTC + h)
+			present[utc] = append(present[utc], c.Author)
+		}
+	}
+	keys := make([]string, 24)
+	for h := range present {
+		sort.Strings(present[h])
+		keys[h] = strings.Join(present[h], "\x00")
+	}
+
+	// Start at a boundary so a window spanning midnight UTC isn't cut in two
+	origin := 0
+	for h := 0; h < 24; h++ {
+		if keys[h] != keys[mod24(h-1)] {
+			origin = h
+			break
+		}
+	}
+
+	windows := []models.OverlapWindow{}
+	for offset := 0; offset < 24; {
+		start := mod24(origin + offset)
+		length := 1
+		for offset+length < 24 && keys[mod24(start+length)] == keys[start] {
+			length++
+		}
+		if len(present[start]) >= 2 {
+			windows = append(windows, models.OverlapWindow{
+				StartUTC:     start,
+				EndUTC:       mod24(start + length),
+				Contributors: present[start],
+			})
+		}
+		offset += length
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		return len(windows[i].Contributors) > len(windows[j].Contributors)
+	})
+	return windows
+}
+
+// formatUTCOffset renders minutes east of UTC as "UTC+05:30"
+func formatUTCOffset(minutes int) string {
+	signChar := "+"
+	if minutes < 0 {
+		signChar = "-"
+		minutes = -minutes
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", signChar, minutes/60, minutes%60)
+}
+
+// wrapOffset keeps an hour offset inside the real-world range UTC-11..UTC+12
+func wrapOffset(hours int) int {
+	hours = mod24(hours)
+	if hours > 12 {
+		hours -= 24
+	}
+	return hours
+}
+
+func mod24(hour int) int {
+	return ((hour % 24) + 24) % 24
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,357 @@
+package analysis
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/mod/modfile"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// workspaceConfigs are root-level files that declare workspace members but aren't manifests
+var workspaceConfigs = []string{"go.work", "pnpm-workspace.yaml", "lerna.json", "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel"}
+
+// maxWorkspacePackages caps the per-package breakdown, shallowest packages first
+const maxWorkspacePackages = 100
+
+// recentChurnWindow is how far back a commit counts as recent churn for a package
+const recentChurnWindow = 90 * 24 * time.Hour
+
+// FindWorkspaceConfigs picks the workspace declarations out of the tree paths
+// (package.json and Cargo.toml come in with the manifests)
+func FindWorkspaceConfigs(paths []string) []string {
+	present := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		present[p] = true
+	}
+	var configs []string
+	for _, config := range workspaceConfigs {
+		if present[config] {
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// DetectWorkspace finds the packages of a monorepo: Go modules (with or without go.work),
+// npm/yarn/pnpm/lerna workspaces, Cargo workspace members and Bazel packages. sizes is
+// every blob in the tree; files holds the fetched manifests and workspace configs.
+// Monorepo is only set when there is more than one package.
+func DetectWorkspace(sizes map[string]int, files map[string]string) models.WorkspaceReport {
+	report := models.WorkspaceReport{Tools: []string{}, Packages: []models.PackageReport{}}
+
+	// Directories holding each kind of package manifest
+	manifestDirs := map[string][]string{}
+	for _, p := range sortedKeys(sizes) {
+		if isVendored(p) {
+			continue
+		}
+		switch path.Base(p) {
+		case "go.mod", "package.json", "Cargo.toml", "BUILD", "BUILD.bazel":
+			manifestDirs[path.Base(p)] = append(manifestDirs[path.Base(p)], path.Dir(p))
+		}
+	}
+
+	tools := make(map[string]bool)
+	packages := make(map[string]models.PackageReport)
+	add := func(dir, kind, name string) {
+		if _, ok := packages[dir]; !ok {
+			packages[dir] = models.PackageReport{Path: dir, Kind: kind, Name: firstNonEmpty(name, dir)}
+		}
+	}
+
+	// Go: every go.mod is a module; go.work only confirms which ones are meant together
+	if goWork, ok := files["go.work"]; ok {
+		tools["go-work"] = true
+		if work, err := modfile.ParseWork("go.work", []byte(goWork), nil); err == nil {
+			for _, use := range work.Use {
+				dir := path.Clean(use.Path)
+				add(dir, "go", goModulePath(files, dir))
+			}
+		}
+	}
+	if len(manifestDirs["go.mod"]) > 1 {
+		tools["go-modules"] = true
+		for _, dir := range manifestDirs["go.mod"] {
+			add(dir, "go", goModulePath(files, dir))
+		}
+	}
+
+	// npm, yarn and pnpm: workspace globs from the root package.json, pnpm-workspace.yaml or lerna.json
+	var npmGlobs []string
+	if content, ok := files["package.json"]; ok {
+		var pkg struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal([]byte(content), &pkg) == nil && len(pkg.Workspaces) > 0 {
+			// Either a list of globs or yarn's {"packages": [...]}
+			var globs []string
+			var nested struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(pkg.Workspaces, &globs) != nil && json.Unmarshal(pkg.Workspaces, &nested) == nil {
+				globs = nested.Packages
+			}
+			if len(globs) > 0 {
+				tools["npm-workspaces"] = true
+				npmGlobs = append(npmGlobs, globs...)
+			}
+		}
+	}
+	if content, ok := files["pnpm-workspace.yaml"]; ok {
+		var config struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal([]byte(content), &config) == nil && len(config.Packages) > 0 {
+			tools["pnpm-workspaces"] = true
+			npmGlobs = append(npmGlobs, config.Packages...)
+		}
+	}
+	if content, ok := files["lerna.json"]; ok {
+		var config struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal([]byte(content), &config) == nil {
+			tools["lerna"] = true
+			if len(config.Packages) == 0 {
+				config.Packages = []string{"packages/*"} // lerna's default
+			}
+			npmGlobs = append(npmGlobs, config.Packages...)
+		}
+	}
+	for _, dir := range matchWorkspaceGlobs(npmGlobs, manifestDirs["package.json"]) {
+		add(dir, "npm", packageJSONName(files, dir))
+	}
+
+	// Cargo: [workspace] members of the root Cargo.toml
+	if content, ok := files["Cargo.toml"]; ok {
+		var manifest struct {
+			Workspace struct {
+				Members []string `toml:"members"`
+				Exclude []string `toml:"exclude"`
+			} `toml:"workspace"`
+		}
+		if toml.Unmarshal([]byte(content), &manifest) == nil && len(manifest.Workspace.Members) > 0 {
+			tools["cargo-workspace"] = true
+			globs := manifest.Workspace.Members
+			for _, excluded := range manifest.Workspace.Exclude {
+				globs = append(globs, "!"+excluded)
+			}
+			for _, dir := range matchWorkspaceGlobs(globs, manifestDirs["Cargo.toml"]) {
+				add(dir, "cargo", cargoPackageName(files, dir))
+			}
+		}
+	}
+
+	// Bazel: each directory with a BUILD file is a package, addressed as //dir
+	_, hasModule := sizes["MODULE.bazel"]
+	_, hasWorkspace := sizes["WORKSPACE"]
+	_, hasWorkspaceBazel := sizes["WORKSPACE.bazel"]
+	if hasModule || hasWorkspace || hasWorkspaceBazel {
+		tools["bazel"] = true
+		for _, dir := range append(manifestDirs["BUILD"], manifestDirs["BUILD.bazel"]...) {
+			if dir == "." {
+				add(dir, "bazel", "//")
+			} else {
+				add(dir, "bazel", "//"+dir)
+			}
+		}
+	}
+
+	if len(packages) < 2 {
+		return report
+	}
+	report.Monorepo = true
+	report.Tools = sortedKeys(tools)
+
+	dirs := sortedKeys(packages)
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return packageDepth(dirs[i]) < packageDepth(dirs[j])
+	})
+	if len(dirs) > maxWorkspacePackages {
+		dirs = dirs[:maxWorkspacePackages]
+		report.Truncated = true
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		report.Packages = append(report.Packages, packages[dir])
+	}
+	return report
+}
+
+// ScopePackages fills each package's slice of the tree: files, size, languages, tests and
+// declared dependencies. A file belongs to the deepest package containing it, so a root
+// Go module doesn't also count its nested modules.
+func ScopePackages(workspace *models.WorkspaceReport, sizes map[string]int, files map[string]string, deps models.DependencyInventory) {
+	if len(workspace.Packages) == 0 {
+		return
+	}
+
+	dirs := make([]string, len(workspace.Packages))
+	for i, pkg := range workspace.Packages {
+		dirs[i] = pkg.Path
+	}
+
+	scopedSizes := make([]map[string]int, len(dirs))
+	scopedFiles := make([]map[string]string, len(dirs))
+	for i := range dirs {
+		scopedSizes[i] = make(map[string]int)
+		scopedFiles[i] = make(map[string]string)
+	}
+	for p, size := range sizes {
+		if i := owningPackage(dirs, p); i >= 0 {
+			scopedSizes[i][p] = size
+			if content, ok := files[p]; ok {
+				scopedFiles[i][p] = content
+			}
+		}
+	}
+
+	for i := range workspace.Packages {
+		pkg := &workspace.Packages[i]
+		pkg.Files = len(scopedSizes[i])
+		pkg.Bytes = 0
+		for _, size := range scopedSizes[i] {
+			pkg.Bytes += size
+		}
+		pkg.Languages = LanguageBytes(scopedSizes[i])
+		pkg.Contributors = []models.PackageContributor{}
+
+		var scopedDeps models.DependencyInventory
+		for _, dep := range deps.Dependencies {
+			if owningPackage(dirs, dep.Source) == i {
+				scopedDeps.Dependencies = append(scopedDeps.Dependencies, dep)
+				if dep.Direct {
+					pkg.Dependencies++
+				}
+			}
+		}
+		pkg.Testing = BuildTestReport(scopedSizes[i], scopedFiles[i], scopedDeps)
+	}
+}
+
+// ApplyPackageHistory sets a package's commit counts and contributors from the commits
+// touching it; commits within recentChurnWindow of asOf count as recent churn
+func ApplyPackageHistory(pkg *models.PackageReport, commits []models.CommitActivity, asOf time.Time) {
+	pkg.Commits = len(commits)
+	pkg.RecentCommits = 0
+
+	byAuthor := make(map[string]int)
+	for _, commit := range commits {
+		if !commit.Date.Before(asOf.Add(-recentChurnWindow)) {
+			pkg.RecentCommits++
+		}
+		if commit.Author != "" {
+			byAuthor[commit.Author]++
+		}
+	}
+
+	pkg.Contributors = []models.PackageContributor{}
+	for author, count := range byAuthor {
+		pkg.Contributors = append(pkg.Contributors, models.PackageContributor{Login: author, Commits: count})
+	}
+	sort.Slice(pkg.Contributors, func(i, j int) bool {
+		if pkg.Contributors[i].Commits != pkg.Contributors[j].Commits {
+			return pkg.Contributors[i].Commits > pkg.Contributors[j].Commits
+		}
+		return pkg.Contributors[i].Login < pkg.Contributors[j].Login
+	})
+}
+
+// owningPackage returns the index of the deepest package directory containing filePath, or -1
+func owningPackage(dirs []string, filePath string) int {
+	best, bestDepth := -1, -1
+	for i, dir := range dirs {
+		if (dir == "." || strings.HasPrefix(filePath, dir+"/")) && packageDepth(dir) > bestDepth {
+			best, bestDepth = i, packageDepth(dir)
+		}
+	}
+	return best
+}
+
+func packageDepth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// matchWorkspaceGlobs returns the candidate directories matched by the workspace globs;
+// "!" globs exclude and "**" matches any number of directories
+func matchWorkspaceGlobs(globs []string, candidates []string) []string {
+	var matched []string
+	for _, dir := range candidates {
+		included := false
+		for _, glob := range globs {
+			negated := strings.HasPrefix(glob, "!")
+			glob = strings.Trim(strings.TrimPrefix(strings.TrimPrefix(glob, "!"), "./"), "/")
+			if matchGlobSegments(strings.Split(glob, "/"), strings.Split(dir, "/")) {
+				included = !negated
+			}
+		}
+		if included {
+			matched = append(matched, dir)
+		}
+	}
+	return matched
+}
+
+func matchGlobSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(parts); skip++ {
+			if matchGlobSegments(pattern[1:], parts[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], parts[1:])
+}
+
+// goModulePath reads the module path of dir/go.mod, when it was fetched
+func goModulePath(files map[string]string, dir string) string {
+	modPath := path.Join(dir, "go.mod")
+	if content, ok := files[modPath]; ok {
+		return modfile.ModulePath([]byte(content))
+	}
+	return ""
+}
+
+// packageJSONName reads the name of dir/package.json, when it was fetched
+func packageJSONName(files map[string]string, dir string) string {
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if content, ok := files[path.Join(dir, "package.json")]; ok {
+		json.Unmarshal([]byte(content), &pkg)
+	}
+	return pkg.Name
+}
+
+// cargoPackageName reads the [package] name of dir/Cargo.toml, when it was fetched
+func cargoPackageName(files map[string]string, dir string) string {
+	var manifest struct {
+		Package struct {
+			Name string `toml:"name"`
+		} `toml:"package"`
+	}
+	if content, ok := files[path.Join(dir, "Cargo.toml")]; ok {
+		toml.Unmarshal([]byte(content), &manifest)
+	}
+	return manifest.Package.Name
+}
@@ -4,40 +4,98 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 var GlobalDatabaseAccessor *gorm.DB
 
+// InitializeDatabase connects and refuses to start on a schema that needs migrating
 func InitializeDatabase() {
+	database, err := Connect()
+	if err != nil {
+		log.Fatalf("Critical: %v", err)
+	}
+
+	if err := CheckSchema(database); err != nil {
+		log.Fatalf("Critical: %v", err)
+	}
+	GlobalDatabaseAccessor = database
+	fmt.Println("Database schema is up to date")
+}
+
+// Connect opens Postgres when DATABASE_URL is set, the local SQLite file otherwise, and
+// applies the pool settings. It does not touch the schema; that is the migrate command's job.
+func Connect() (*gorm.DB, error) {
 	productionDatabaseURL := os.Getenv("DATABASE_URL")
 
+	var database *gorm.DB
 	var databaseError error
 
 	if productionDatabaseURL != "" {
 		fmt.Println("Connecting to Production Database...")
-		// Add Postgres connection logic here later
+		database, databaseError = gorm.Open(postgres.Open(productionDatabaseURL), &gorm.Config{})
+
+		if databaseError != nil {
+			return nil, fmt.Errorf("could not connect to Postgres: %w", databaseError)
+		}
+		fmt.Println("Successfully connected to Postgres")
 	} else {
 		fmt.Println("Connecting to Development Database...")
 		localStoragePath := "dev.db"
-		GlobalDatabaseAccessor, databaseError = gorm.Open(sqlite.Open(localStoragePath), &gorm.Config{})
+		database, databaseError = gorm.Open(sqlite.Open(localStoragePath), &gorm.Config{})
 
 		if databaseError != nil {
-			log.Fatalf("Critical: Could not connect to local SQLite: %v", databaseError)
+			return nil, fmt.Errorf("could not connect to local SQLite: %w", databaseError)
 		}
 		fmt.Println("Successfully connected to local SQLite at:", localStoragePath)
 	}
 
-	// AutoMigrate automatically creates the tables based on the NEW models package
-	migrationError := GlobalDatabaseAccessor.AutoMigrate(
-		&models.AnalyticsReport{},
-	)
+	if err := configurePool(database); err != nil {
+		return nil, fmt.Errorf("could not configure the connection pool: %w", err)
+	}
+	return database, nil
+}
 
-	if migrationError != nil {
-		log.Fatalf("Critical: Database migration failed: %v", migrationError)
+// configurePool applies the DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME and
+// DB_CONN_MAX_IDLE_TIME settings; unset variables keep database/sql's defaults
+func configurePool(database *gorm.DB) error {
+	sqlDB, err := database.DB()
+	if err != nil {
+		return err
+	}
+
+	if value := os.Getenv("DB_MAX_OPEN_CONNS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DB_MAX_OPEN_CONNS: %w", err)
+		}
+		sqlDB.SetMaxOpenConns(n)
+	}
+	if value := os.Getenv("DB_MAX_IDLE_CONNS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DB_MAX_IDLE_CONNS: %w", err)
+		}
+		sqlDB.SetMaxIdleConns(n)
+	}
+	if value := os.Getenv("DB_CONN_MAX_LIFETIME"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("DB_CONN_MAX_LIFETIME: %w", err)
+		}
+		sqlDB.SetConnMaxLifetime(d)
+	}
+	if value := os.Getenv("DB_CONN_MAX_IDLE_TIME"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("DB_CONN_MAX_IDLE_TIME: %w", err)
+		}
+		sqlDB.SetConnMaxIdleTime(d)
 	}
-	fmt.Println("Database structure updated successfully!")
+	return nil
 }
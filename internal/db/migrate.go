@@ -0,0 +1,243 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/migrations"
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered schema change with its SQL in both directions
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ErrSchemaOutdated means the database and the binary disagree on the schema version
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// migrationLockID is the Postgres advisory lock that keeps replicas from migrating at the same time
+const migrationLockID = 4_815_162_342
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// addColumnIfNotExists finds the ADD COLUMN IF NOT EXISTS that SQLite doesn't support
+var addColumnIfNotExists = regexp.MustCompile("(?is)^\\s*ALTER TABLE\\s+[`\"]?(\\w+)[`\"]?\\s+ADD COLUMN IF NOT EXISTS\\s+[`\"]?(\\w+)[`\"]?")
+
+// LoadMigrations reads the embedded migrations of a dialect ("postgres" or "sqlite"), oldest first
+func LoadMigrations(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations.Files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(migrations.Files, dialect+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var loaded []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", migration.Version, migration.Name)
+		}
+		loaded = append(loaded, *migration)
+	}
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].Version < loaded[j].Version
+	})
+	return loaded, nil
+}
+
+// SchemaVersion returns the newest applied migration, 0 for a database never migrated
+func SchemaVersion(database *gorm.DB) (int, error) {
+	if !database.Migrator().HasTable("schema_migrations") {
+		return 0, nil
+	}
+	var version int
+	err := database.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error
+	return version, err
+}
+
+// CheckSchema refuses a database that is behind (or ahead of) the migrations this binary ships
+func CheckSchema(database *gorm.DB) error {
+	available, err := LoadMigrations(database.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	current, err := SchemaVersion(database)
+	if err != nil {
+		return err
+	}
+
+	latest := 0
+	if len(available) > 0 {
+		latest = available[len(available)-1].Version
+	}
+	if current != latest {
+		return fmt.Errorf("%w: database is at version %d, this binary needs %d (run \"server migrate\")", ErrSchemaOutdated, current, latest)
+	}
+	return nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own transaction
+func MigrateUp(database *gorm.DB) ([]Migration, error) {
+	available, err := LoadMigrations(database.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withMigrationLock(database, func(conn *gorm.DB) error {
+		current, err := SchemaVersion(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range available {
+			if migration.Version <= current {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execStatements(tx, migration.Up); err != nil {
+					return err
+				}
+				return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now().UTC()).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown reverts the newest `steps` applied migrations, newest first
+func MigrateDown(database *gorm.DB, steps int) ([]Migration, error) {
+	available, err := LoadMigrations(database.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = withMigrationLock(database, func(conn *gorm.DB) error {
+		for i := len(available) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := available[i]
+			current, err := SchemaVersion(conn)
+			if err != nil {
+				return err
+			}
+			if migration.Version > current {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d (%s) has no down file", migration.Version, migration.Name)
+			}
+			err = conn.Transaction(func(tx *gorm.DB) error {
+				if err := execStatements(tx, migration.Down); err != nil {
+					return err
+				}
+				return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withMigrationLock runs fn on a single connection holding the migration lock. On Postgres
+// that is an advisory lock; SQLite's own file locking already serializes the writers.
+func withMigrationLock(database *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return database.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "postgres" {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return fmt.Errorf("could not take the migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+		}
+
+		err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			applied_at timestamp NOT NULL
+		)`).Error
+		if err != nil {
+			return fmt.Errorf("could not create schema_migrations: %w", err)
+		}
+		return fn(conn)
+	})
+}
+
+// execStatements runs a migration file one statement at a time. Statements end with a
+// semicolon at the end of a line; migrations don't put semicolons inside string literals.
+func execStatements(tx *gorm.DB, script string) error {
+	var statement strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if statement.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if err := execStatement(tx, statement.String()); err != nil {
+				return err
+			}
+			statement.Reset()
+		}
+	}
+	if strings.TrimSpace(statement.String()) != "" {
+		return execStatement(tx, statement.String())
+	}
+	return nil
+}
+
+// execStatement runs one statement. SQLite has no ADD COLUMN IF NOT EXISTS, so there the
+// column is looked up first and the clause dropped.
+func execStatement(tx *gorm.DB, statement string) error {
+	if tx.Dialector.Name() == "sqlite" {
+		if match := addColumnIfNotExists.FindStringSubmatch(statement); match != nil {
+			if tx.Migrator().HasColumn(match[1], match[2]) {
+				return nil
+			}
+			statement = strings.Replace(statement, " IF NOT EXISTS", "", 1)
+		}
+	}
+	return tx.Exec(statement).Error
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDatabase opens an empty SQLite database in a temporary directory
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	return database
+}
+
+func TestMigrateUpFromEmpty(t *testing.T) {
+	database := openTestDatabase(t)
+
+	available, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if err := CheckSchema(database); err == nil {
+		t.Fatal("CheckSchema accepted an empty database")
+	}
+
+	applied, err := MigrateUp(database)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(applied) != len(available) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(available))
+	}
+	if err := CheckSchema(database); err != nil {
+		t.Errorf("CheckSchema after MigrateUp: %v", err)
+	}
+
+	// A second run has nothing to do
+	applied, err = MigrateUp(database)
+	if err != nil {
+		t.Fatalf("second MigrateUp: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second MigrateUp applied %d migrations, want 0", len(applied))
+	}
+	if err := CheckSchema(database); err != nil {
+		t.Errorf("CheckSchema after second MigrateUp: %v", err)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	database := openTestDatabase(t)
+	available, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if _, err := MigrateUp(database); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	reverted, err := MigrateDown(database, len(available))
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if len(reverted) != len(available) {
+		t.Errorf("reverted %d migrations, want %d", len(reverted), len(available))
+	}
+	if version, err := SchemaVersion(database); err != nil || version != 0 {
+		t.Errorf("SchemaVersion after MigrateDown = %d, %v, want 0", version, err)
+	}
+
+	if _, err := MigrateUp(database); err != nil {
+		t.Fatalf("MigrateUp after MigrateDown: %v", err)
+	}
+	if err := CheckSchema(database); err != nil {
+		t.Errorf("CheckSchema after round trip: %v", err)
+	}
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	sqliteMigrations, err := LoadMigrations("sqlite")
+	if err != nil {
+		t.Fatalf("LoadMigrations(sqlite): %v", err)
+	}
+	postgresMigrations, err := LoadMigrations("postgres")
+	if err != nil {
+		t.Fatalf("LoadMigrations(postgres): %v", err)
+	}
+
+	if len(sqliteMigrations) != len(postgresMigrations) {
+		t.Fatalf("%d sqlite migrations, %d postgres migrations", len(sqliteMigrations), len(postgresMigrations))
+	}
+	for i := range sqliteMigrations {
+		s, p := sqliteMigrations[i], postgresMigrations[i]
+		if s.Version != p.Version || s.Name != p.Name {
+			t.Errorf("migration %d: sqlite %d_%s, postgres %d_%s", i, s.Version, s.Name, p.Version, p.Name)
+		}
+		if s.Down == "" || p.Down == "" {
+			t.Errorf("migration %d_%s has no down file", s.Version, s.Name)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTextBytes is how much of a text file we keep; anything longer is cut and marked truncated
+const maxTextBytes = 1 << 20
+
+// maxBlobBytes is the blobs API's limit; bigger files can't be fetched at all
+const maxBlobBytes = 100 << 20
+
+// sniffBytes is how far into a file we look for NUL bytes, the same heuristic git uses
+const sniffBytes = 8000
+
+// binaryExtensions are file types that are never worth decoding as text
+var binaryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".webp": true, ".psd": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tgz": true, ".tar": true, ".bz2": true, ".xz": true, ".7z": true, ".rar": true,
+	".jar": true, ".war": true, ".class": true, ".exe": true, ".dll": true, ".so": true, ".dylib": true, ".o": true, ".a": true,
+	".wasm": true, ".pyc": true, ".bin": true, ".dat": true, ".db": true, ".sqlite": true, ".sqlite3": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp3": true, ".mp4": true, ".wav": true, ".ogg": true, ".webm": true, ".mov": true, ".avi": true, ".flac": true,
+}
+
+// ContentDescriptor says what a fetched file actually is before anyone treats it as text
+type ContentDescriptor struct {
+	Size      int    `json:"size"`      // Full size in bytes, even when truncated
+	Encoding  string `json:"encoding"`  // "utf-8" or "binary"
+	MIMEType  string `json:"mime_type"` // Sniffed from the content
+	IsBinary  bool   `json:"is_binary"` // Content is left empty for binaries
+	Truncated bool   `json:"truncated"`
+	Source    string `json:"source"` // "contents" or "blob" (the fallback for files over 1 MB)
+}
+
+// File is a fetched file with its descriptor
+type File struct {
+	Path       string
+	Content    string
+	Descriptor ContentDescriptor
+}
+
+// FetchFile fetches a file through the contents API, falling back to the git blobs API
+// (which serves up to 100 MB) for files the contents API won't return inline.
+// Binary files are detected and come back described but without content.
+func (c *Client) FetchFile(ctx context.Context, owner, repo, filePath string) (*File, error) {
+	return c.FetchFileAt(ctx, owner, repo, "", filePath)
+}
+
+// FetchFileAt is FetchFile at a branch, tag or commit SHA (the default branch when empty)
+func (c *Client) FetchFileAt(ctx context.Context, owner, repo, ref, filePath string) (*File, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, filePath)
+	if ref != "" {
+		url += "?ref=" + neturl.QueryEscape(ref)
+	}
+
+	var content FileContent
+	if err := c.get(ctx, url, &content); err != nil {
+		return nil, fmt.Errorf("failed to fetch file content for %s: %w", filePath, err)
+	}
+
+	source := "contents"
+	var data []byte
+	switch content.Encoding {
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(content.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 content: %w", err)
+		}
+		data = decoded
+	case "none":
+		// Files between 1 and 100 MB come back with no content; their blob still can. Only the
+		// part we keep is downloaded - the full size is already known from the metadata.
+		if content.SHA == "" || content.Size > maxBlobBytes {
+			return nil, fmt.Errorf("failed to fetch file content for %s: %w", filePath, ErrFileTooLarge)
+		}
+		blob, err := c.FetchBlob(ctx, owner, repo, content.SHA, maxTextBytes+utf8.UTFMax)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch blob for %s: %w", filePath, err)
+		}
+		data, source = blob, "blob"
+	default:
+		data = []byte(content.Content)
+	}
+
+	size := max(content.Size, len(data))
+	file := &File{Path: filePath, Descriptor: describeContent(filePath, data, size)}
+	file.Descriptor.Source = source
+	if !file.Descriptor.IsBinary {
+		if file.Descriptor.Truncated {
+			data = trimToRune(data[:min(len(data), maxTextBytes)])
+		}
+		file.Content = string(data)
+	}
+	return file, nil
+}
+
+// FetchBlob fetches the raw bytes of a blob by SHA, reading at most limit bytes of it
+func (c *Client) FetchBlob(ctx context.Context, owner, repo, sha string, limit int) ([]byte, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/blobs/%s", owner, repo, sha)
+	return c.getRaw(ctx, url, int64(limit))
+}
+
+// IsBinaryPath reports whether a path has a file extension that is always binary
+func IsBinaryPath(filePath string) bool {
+	return binaryExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// describeContent sniffs a file: known binary extension, a NUL byte near the start,
+// or bytes that aren't valid UTF-8 all mean it isn't text we can show to a model. size is
+// the full file size, which is more than len(data) when only the start was downloaded.
+func describeContent(filePath string, data []byte, size int) ContentDescriptor {
+	sniff := data
+	if len(sniff) > sniffBytes {
+		sniff = sniff[:sniffBytes]
+	}
+
+	descriptor := ContentDescriptor{
+		Size:     size,
+		Encoding: "utf-8",
+		MIMEType: http.DetectContentType(sniff),
+	}
+
+	if IsBinaryPath(filePath) || bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(trimToRune(sniff)) {
+		descriptor.Encoding = "binary"
+		descriptor.IsBinary = true
+		return descriptor
+	}
+
+	descriptor.Truncated = size > maxTextBytes
+	return descriptor
+}
+
+// trimToRune drops a partial UTF-8 sequence left at the end by slicing
+func trimToRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size != 1 {
+			return data
+		}
+		data = data[:len(data)-1]
+	}
+	return data
+}
@@ -1,31 +1,84 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 )
 
+// defaultFetchConcurrency is how many files FetchMultipleFiles downloads at once
+const defaultFetchConcurrency = 8
+
 type Client struct {
-	token      string
-	httpClient *http.Client
+	token            string
+	httpClient       *http.Client
+	fetchConcurrency int
 }
 
 func NewClient() *Client {
+	concurrency := defaultFetchConcurrency
+	if value, err := strconv.Atoi(os.Getenv("GITHUB_FETCH_CONCURRENCY")); err == nil && value > 0 {
+		concurrency = value
+	}
+
 	return &Client{
 		token: os.Getenv("GITHUB_TOKEN"),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second, // Increased timeout for larger requests
 		},
+		fetchConcurrency: concurrency,
+	}
+}
+
+// SetFetchConcurrency changes how many files FetchMultipleFiles downloads in parallel
+func (client *Client) SetFetchConcurrency(limit int) {
+	if limit > 0 {
+		client.fetchConcurrency = limit
+	}
+}
+
+// APIError is a non-200 response from the GitHub API
+type APIError struct {
+	URL         string
+	StatusCode  int
+	RateLimited bool // GitHub answers 403 both for rate limits and for missing permissions
+	TooLarge    bool // A 403 for a file over the API's 100 MB limit
+}
+
+// errorBodyBytes is how much of an error response is read to tell a 403 apart
+const errorBodyBytes = 4 << 10
+
+func newAPIError(url string, response *http.Response) *APIError {
+	apiErr := &APIError{
+		URL:         url,
+		StatusCode:  response.StatusCode,
+		RateLimited: response.StatusCode == http.StatusTooManyRequests || response.Header.Get("X-RateLimit-Remaining") == "0",
+	}
+	if response.StatusCode == http.StatusForbidden && !apiErr.RateLimited {
+		// e.g. {"message": "... The requested blob is too large to fetch via the API", "errors": [{"code": "too_large"}]}
+		body, _ := io.ReadAll(io.LimitReader(response.Body, errorBodyBytes))
+		lowered := strings.ToLower(string(body))
+		apiErr.TooLarge = strings.Contains(lowered, "too_large") || strings.Contains(lowered, "too large")
 	}
+	return apiErr
 }
 
-func (client *Client) get(url string, target interface{}) error {
-	request, err := http.NewRequest("GET", url, nil)
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github api error: %s returned status %d", e.URL, e.StatusCode)
+}
+
+func (client *Client) get(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
@@ -41,15 +94,39 @@ func (client *Client) get(url string, target interface{}) error {
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("github api error: %s returned status %d", url, response.StatusCode)
+		return newAPIError(url, response)
 	}
 
 	return json.NewDecoder(response.Body).Decode(target)
 }
 
+// getRaw fetches a raw media type response, reading at most limit bytes of it
+func (client *Client) getRaw(ctx context.Context, url string, limit int64) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", "token "+client.token)
+	request.Header.Set("Accept", "application/vnd.github.raw")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newAPIError(url, response)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, limit))
+}
+
 // getWithPagination fetches data and returns the next page URL if available
-func (client *Client) getWithPagination(url string, target interface{}) (string, error) {
-	request, err := http.NewRequest("GET", url, nil)
+func (client *Client) getWithPagination(ctx context.Context, url string, target interface{}) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", err
 	}
@@ -65,7 +142,7 @@ func (client *Client) getWithPagination(url string, target interface{}) (string,
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("github api error: %s returned status %d", url, response.StatusCode)
+		return "", newAPIError(url, response)
 	}
 
 	// Parse Link header for pagination
@@ -102,43 +179,236 @@ func ExtractOwnerAndRepo(repoURL string) (string, string, error) {
 	return parts[1], parts[2], nil
 }
 
-func (client *Client) FetchCommitsRaw(owner, repo, since, until string) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?per_page=100", owner, repo)
+// maxCommitPages is the safety limit on commit pagination: 50 pages of 100 commits
+const maxCommitPages = 50
 
-	if since != "" {
-		url += fmt.Sprintf("&since=%sT00:00:00Z", since)
-	}
+// FetchCommitHistory lists the commits reachable from ref (the default branch when empty),
+// newest first, as rows for the commits table. The REST listing has the identities and
+// signatures; GraphQL adds each timestamp's original offset and the line stats.
+func (client *Client) FetchCommitHistory(ctx context.Context, owner, repo, ref string) ([]models.Commit, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?per_page=100", owner, repo)
 
-	if until != "" {
-		url += fmt.Sprintf("&until=%sT23:59:59Z", until)
+	if ref != "" {
+		url += "&sha=" + neturl.QueryEscape(ref)
 	}
 
-	var allCommits []map[string]interface{}
+	var commits []models.Commit
 	pageCount := 0
 
 	for url != "" {
-		var pageCommits []map[string]interface{}
+		var page []restCommit
 		pageCount++
 
 		fmt.Printf("  Fetching page %d of commits...\n", pageCount)
 
-		nextURL, err := client.getWithPagination(url, &pageCommits)
+		nextURL, err := client.getWithPagination(ctx, url, &page)
 		if err != nil {
 			return nil, err
 		}
 
-		allCommits = append(allCommits, pageCommits...)
+		for _, commit := range page {
+			commits = append(commits, commit.record(owner+"/"+repo))
+		}
 
 		// Move to next page (empty string means no more pages)
 		url = nextURL
 
-		// Safety limit: stop at 50 pages (5000 commits) to prevent infinite loops
-		if pageCount >= 50 {
-			fmt.Printf("  Reached page limit (50 pages), stopping pagination\n")
+		// Safety limit to prevent infinite loops
+		if pageCount >= maxCommitPages {
+			fmt.Printf("  Reached page limit (%d pages), stopping pagination\n", maxCommitPages)
 			break
 		}
 	}
 
-	fmt.Printf("  Fetched %d total commits across %d pages\n", len(allCommits), pageCount)
-	return allCommits, nil
+	fmt.Printf("  Fetched %d total commits across %d pages\n", len(commits), pageCount)
+	if len(commits) == 0 {
+		return commits, nil
+	}
+
+	details, err := client.fetchCommitDetails(ctx, owner, repo, commits[0].SHA, len(commits))
+	if err != nil {
+		fmt.Printf("Error fetching commit timezones and stats, falling back to UTC: %v\n", err)
+	}
+	for i := range commits {
+		detail, ok := details[commits[i].SHA]
+		if !ok {
+			continue
+		}
+		_, authoredOffset := detail.AuthoredAt.Zone()
+		_, committedOffset := detail.CommittedAt.Zone()
+		commits[i].AuthoredOffset = authoredOffset / 60
+		commits[i].CommittedOffset = committedOffset / 60
+		commits[i].OffsetKnown = true
+		commits[i].Additions = detail.Additions
+		commits[i].Deletions = detail.Deletions
+		commits[i].StatsKnown = true
+	}
+	return commits, nil
+}
+
+// restCommit is one entry of the REST commits listing
+type restCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+		Verification struct {
+			Verified  bool   `json:"verified"`
+			Reason    string `json:"reason"`
+			Signature string `json:"signature"`
+		} `json:"verification"`
+	} `json:"commit"`
+	Author *struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	} `json:"author"`
+}
+
+// author is the GitHub login with its avatar, falling back to the git author name
+// like FetchEverything does
+func (commit restCommit) author() (string, string) {
+	if commit.Author != nil && commit.Author.Login != "" {
+		return commit.Author.Login, commit.Author.AvatarURL
+	}
+	return commit.Commit.Author.Name, ""
+}
+
+// activity converts the commit to the report's commit record (dates stay in UTC)
+func (commit restCommit) activity() models.CommitActivity {
+	author, _ := commit.author()
+	return models.CommitActivity{
+		SHA:                commit.SHA,
+		Author:             author,
+		Date:               commit.Commit.Author.Date,
+		Signature:          signatureType(commit.Commit.Verification.Signature),
+		Verified:           commit.Commit.Verification.Verified,
+		VerificationReason: commit.Commit.Verification.Reason,
+	}
+}
+
+// record converts the commit to a commits table row (times in UTC, offsets and stats unknown)
+func (commit restCommit) record(fullName string) models.Commit {
+	record := models.Commit{
+		Repo:               fullName,
+		SHA:                commit.SHA,
+		AuthorName:         commit.Commit.Author.Name,
+		AuthorEmail:        commit.Commit.Author.Email,
+		AuthoredAt:         commit.Commit.Author.Date.UTC(),
+		CommittedAt:        commit.Commit.Committer.Date.UTC(),
+		Message:            commit.Commit.Message,
+		Signature:          signatureType(commit.Commit.Verification.Signature),
+		Verified:           commit.Commit.Verification.Verified,
+		VerificationReason: commit.Commit.Verification.Reason,
+	}
+	if commit.Author != nil {
+		record.AuthorLogin = commit.Author.Login
+		record.AuthorAvatarURL = commit.Author.AvatarURL
+	}
+	return record
+}
+
+// signatureType tells GPG, SSH and S/MIME (x509) signatures apart by their armor header
+func signatureType(signature string) string {
+	switch {
+	case signature == "":
+		return ""
+	case strings.Contains(signature, "BEGIN PGP SIGNATURE"):
+		return "gpg"
+	case strings.Contains(signature, "BEGIN SSH SIGNATURE"):
+		return "ssh"
+	case strings.Contains(signature, "BEGIN SIGNED MESSAGE"), strings.Contains(signature, "BEGIN PKCS7"):
+		return "x509"
+	}
+	return "unknown"
+}
+
+// FetchCommit fetches a single commit by SHA or ref
+func (client *Client) FetchCommit(ctx context.Context, owner, repo, ref string) (*models.CommitActivity, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", owner, repo, escapeRef(ref))
+
+	var commit restCommit
+	if err := client.get(ctx, url, &commit); err != nil {
+		return nil, err
+	}
+	activity := commit.activity()
+	return &activity, nil
+}
+
+// FetchPathCommits lists up to `limit` of the latest commits reachable from ref (the default
+// branch when empty) that touch anything under dir
+func (client *Client) FetchPathCommits(ctx context.Context, owner, repo, ref, dir string, limit int) ([]models.CommitActivity, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?per_page=100&path=%s", owner, repo, neturl.QueryEscape(dir))
+	if ref != "" {
+		url += "&sha=" + neturl.QueryEscape(ref)
+	}
+
+	var activity []models.CommitActivity
+	for url != "" && len(activity) < limit {
+		var page []restCommit
+		nextURL, err := client.getWithPagination(ctx, url, &page)
+		if err != nil {
+			return activity, err
+		}
+		for _, commit := range page {
+			activity = append(activity, commit.activity())
+		}
+		url = nextURL
+	}
+
+	if len(activity) > limit {
+		activity = activity[:limit]
+	}
+	return activity, nil
+}
+
+// CommitFile is one changed file of a commit, with its unified diff
+type CommitFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+	Patch    string `json:"patch"`
+}
+
+// CommitDiff is a commit together with the files it changed
+type CommitDiff struct {
+	SHA   string       `json:"sha"`
+	Files []CommitFile `json:"files"`
+}
+
+// FetchRecentCommitDiffs fetches the patches of the latest `limit` commits reachable from ref
+// (the default branch when empty). Each commit costs one API call, so keep limit small.
+func (client *Client) FetchRecentCommitDiffs(ctx context.Context, owner, repo, ref string, limit int) ([]CommitDiff, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?per_page=%d", owner, repo, limit)
+	if ref != "" {
+		url += "&sha=" + neturl.QueryEscape(ref)
+	}
+
+	var commits []struct {
+		SHA string `json:"sha"`
+	}
+	if err := client.get(ctx, url, &commits); err != nil {
+		return nil, err
+	}
+
+	var diffs []CommitDiff
+	for _, commit := range commits {
+		if err := ctx.Err(); err != nil {
+			return diffs, err
+		}
+		var diff CommitDiff
+		commitURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", owner, repo, commit.SHA)
+		if err := client.get(ctx, commitURL, &diff); err != nil {
+			fmt.Printf("  Warning: could not fetch commit %s: %v\n", commit.SHA, err)
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
 }
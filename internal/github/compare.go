@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// maxComparePages caps the commit listing of a comparison (100 commits per page)
+const maxComparePages = 10
+
+// maxCompareFiles is how many changed files the compare API lists before it stops
+const maxCompareFiles = 300
+
+// compareResponse is the part of GitHub's compare API response we use
+type compareResponse struct {
+	Status       string `json:"status"`
+	AheadBy      int    `json:"ahead_by"`
+	BehindBy     int    `json:"behind_by"`
+	TotalCommits int    `json:"total_commits"`
+	BaseCommit   struct {
+		SHA string `json:"sha"`
+	} `json:"base_commit"`
+	MergeBaseCommit struct {
+		SHA string `json:"sha"`
+	} `json:"merge_base_commit"`
+	Commits []restCommit `json:"commits"`
+	Files   []struct {
+		Filename         string `json:"filename"`
+		PreviousFilename string `json:"previous_filename"`
+		Status           string `json:"status"`
+		Additions        int    `json:"additions"`
+		Deletions        int    `json:"deletions"`
+	} `json:"files"`
+}
+
+// CompareRefs lists the commits, changed files and contributors between two refs.
+// The tree delta is left empty; it needs both trees (see FetchTreeAt).
+func (c *Client) CompareRefs(ctx context.Context, owner, repo, base, head string) (*models.RefComparison, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s?per_page=100", owner, repo, escapeRef(base), escapeRef(head))
+
+	comparison := &models.RefComparison{
+		Repo:         owner + "/" + repo,
+		Base:         base,
+		Head:         head,
+		Commits:      []models.ComparedCommit{},
+		Files:        []models.ChangedFile{},
+		Contributors: []models.ComparedContributor{},
+	}
+
+	contributors := make(map[string]*models.ComparedContributor)
+	for pageCount := 1; url != ""; pageCount++ {
+		var page compareResponse
+		nextURL, err := c.getWithPagination(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		// Every page repeats the summary; the file list only comes with the first
+		if pageCount == 1 {
+			comparison.Status = page.Status
+			comparison.AheadBy = page.AheadBy
+			comparison.BehindBy = page.BehindBy
+			comparison.TotalCommits = page.TotalCommits
+			comparison.BaseSHA = page.BaseCommit.SHA
+			comparison.MergeBaseSHA = page.MergeBaseCommit.SHA
+
+			for _, file := range page.Files {
+				comparison.Files = append(comparison.Files, models.ChangedFile{
+					Path:         file.Filename,
+					PreviousPath: file.PreviousFilename,
+					Status:       file.Status,
+					Additions:    file.Additions,
+					Deletions:    file.Deletions,
+				})
+				comparison.Additions += file.Additions
+				comparison.Deletions += file.Deletions
+			}
+			comparison.FilesTruncated = len(page.Files) >= maxCompareFiles
+		}
+
+		for _, commit := range page.Commits {
+			author, avatar := commit.author()
+
+			message, _, _ := strings.Cut(commit.Commit.Message, "\n")
+			comparison.Commits = append(comparison.Commits, models.ComparedCommit{
+				SHA:     commit.SHA,
+				Author:  author,
+				Message: message,
+				Date:    commit.Commit.Author.Date,
+			})
+
+			if author == "" {
+				continue
+			}
+			if contributors[author] == nil {
+				contributors[author] = &models.ComparedContributor{Login: author}
+			}
+			contributors[author].Commits++
+			if avatar != "" {
+				contributors[author].AvatarURL = avatar
+			}
+		}
+
+		url = nextURL
+		if pageCount >= maxComparePages {
+			break
+		}
+	}
+	comparison.CommitsTruncated = len(comparison.Commits) < comparison.TotalCommits
+
+	for _, contributor := range contributors {
+		comparison.Contributors = append(comparison.Contributors, *contributor)
+	}
+	sort.Slice(comparison.Contributors, func(i, j int) bool {
+		if comparison.Contributors[i].Commits != comparison.Contributors[j].Commits {
+			return comparison.Contributors[i].Commits > comparison.Contributors[j].Commits
+		}
+		return comparison.Contributors[i].Login < comparison.Contributors[j].Login
+	})
+
+	return comparison, nil
+}
+
+// escapeRef escapes a ref for use in a URL path, keeping the slashes of names like "release/1.2"
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, part := range parts {
+		parts[i] = neturl.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,146 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// graphqlURL is GitHub's GraphQL endpoint (it always needs a token, unlike REST)
+const graphqlURL = "https://api.github.com/graphql"
+
+// graphql runs one GraphQL query and decodes its "data" field into target
+func (client *Client) graphql(ctx context.Context, query string, variables map[string]interface{}, target interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", graphqlURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "bearer "+client.token)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("github graphql error: returned status %d", response.StatusCode)
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		return fmt.Errorf("github graphql error: %s", envelope.Errors[0].Message)
+	}
+	return json.Unmarshal(envelope.Data, target)
+}
+
+// commitDetailsQuery walks the history behind a ref ("HEAD" is the default branch). The dates
+// are GitTimestamps, which - unlike REST's commit dates - keep the original UTC offset.
+const commitDetailsQuery = `query($owner: String!, $name: String!, $ref: String!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    object(expression: $ref) {
+      ... on Commit {
+        history(first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes { oid additions deletions author { date } committer { date } }
+        }
+      }
+    }
+  }
+}`
+
+// commitDetail is what GraphQL knows about a commit that the REST listing doesn't
+type commitDetail struct {
+	AuthoredAt  time.Time
+	CommittedAt time.Time
+	Additions   int
+	Deletions   int
+}
+
+// fetchCommitDetails returns the local timestamps and line stats of up to `limit` commits
+// reachable from ref (the default branch when empty), keyed by SHA
+func (client *Client) fetchCommitDetails(ctx context.Context, owner, repo, ref string, limit int) (map[string]commitDetail, error) {
+	details := make(map[string]commitDetail)
+	var cursor interface{}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	for pageCount := 1; len(details) < limit; pageCount++ {
+		var page struct {
+			Repository struct {
+				Object struct {
+					History struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							OID       string `json:"oid"`
+							Additions int    `json:"additions"`
+							Deletions int    `json:"deletions"`
+							Author    struct {
+								Date string `json:"date"`
+							} `json:"author"`
+							Committer struct {
+								Date string `json:"date"`
+							} `json:"committer"`
+						} `json:"nodes"`
+					} `json:"history"`
+				} `json:"object"`
+			} `json:"repository"`
+		}
+
+		variables := map[string]interface{}{"owner": owner, "name": repo, "ref": ref, "cursor": cursor}
+		if err := client.graphql(ctx, commitDetailsQuery, variables, &page); err != nil {
+			return details, err
+		}
+
+		history := page.Repository.Object.History
+		for _, node := range history.Nodes {
+			authored, err := time.Parse(time.RFC3339, node.Author.Date)
+			if err != nil {
+				continue
+			}
+			committed, err := time.Parse(time.RFC3339, node.Committer.Date)
+			if err != nil {
+				committed = authored
+			}
+			details[node.OID] = commitDetail{
+				AuthoredAt:  authored,
+				CommittedAt: committed,
+				Additions:   node.Additions,
+				Deletions:   node.Deletions,
+			}
+		}
+
+		if !history.PageInfo.HasNextPage {
+			break
+		}
+		cursor = history.PageInfo.EndCursor
+
+		// Same safety limit as the REST pagination
+		if pageCount >= maxCommitPages {
+			break
+		}
+	}
+
+	return details, nil
+}
@@ -0,0 +1,91 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"time"
+)
+
+// ErrNoCommits means the repository had no commits yet at the requested date
+var ErrNoCommits = errors.New("repository has no commits")
+
+// ResolvedCommit is the commit a ref or date points at
+type ResolvedCommit struct {
+	SHA     string
+	Date    time.Time // Committer date
+	TreeSHA string    // Root tree of the commit
+}
+
+// commitSummary is the part of a commits API entry needed to resolve refs
+type commitSummary struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	} `json:"commit"`
+}
+
+// ResolveRef turns a branch, tag or (short) SHA into the full SHA of the commit it names.
+// "HEAD" names the head of the default branch.
+func (c *Client) ResolveRef(ctx context.Context, owner, repo, ref string) (*ResolvedCommit, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", owner, repo, escapeRef(ref))
+
+	var commit commitSummary
+	if err := c.get(ctx, url, &commit); err != nil {
+		return nil, fmt.Errorf("failed to resolve ref %s: %w", ref, err)
+	}
+	return &ResolvedCommit{SHA: commit.SHA, Date: commit.Commit.Committer.Date, TreeSHA: commit.Commit.Tree.SHA}, nil
+}
+
+// ResolveCommitAsOf finds the last commit on the default branch made at or before asOf
+func (c *Client) ResolveCommitAsOf(ctx context.Context, owner, repo string, asOf time.Time) (*ResolvedCommit, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?per_page=1&until=%s", owner, repo, neturl.QueryEscape(asOf.UTC().Format(time.RFC3339)))
+
+	var commits []commitSummary
+	if err := c.get(ctx, url, &commits); err != nil {
+		return nil, fmt.Errorf("failed to find the commit as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: no commits on or before %s", ErrNoCommits, asOf.Format(time.RFC3339))
+	}
+	return &ResolvedCommit{SHA: commits[0].SHA, Date: commits[0].Commit.Committer.Date, TreeSHA: commits[0].Commit.Tree.SHA}, nil
+}
+
+// ProtectedBranch is a branch with protection rules and the commit at its head
+type ProtectedBranch struct {
+	Name string
+	SHA  string
+}
+
+// FetchProtectedBranches lists the repository's protected branches
+func (c *Client) FetchProtectedBranches(ctx context.Context, owner, repo string) ([]ProtectedBranch, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches?protected=true&per_page=100", owner, repo)
+
+	var branches []ProtectedBranch
+	for pageCount := 1; url != ""; pageCount++ {
+		var page []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		nextURL, err := c.getWithPagination(ctx, url, &page)
+		if err != nil {
+			return branches, err
+		}
+		for _, branch := range page {
+			branches = append(branches, ProtectedBranch{Name: branch.Name, SHA: branch.Commit.SHA})
+		}
+		url = nextURL
+		if pageCount >= 10 {
+			break
+		}
+	}
+	return branches, nil
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -8,10 +9,22 @@ import (
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 )
 
-func (c *Client) FetchEverything(owner, repoName string) (*models.AnalyticsReport, error) {
+func (c *Client) FetchEverything(ctx context.Context, owner, repoName string) (*models.AnalyticsReport, []models.Commit, error) {
+	return c.FetchEverythingAt(ctx, owner, repoName, "")
+}
+
+// FetchEverythingAt fetches the GitHub part of a report and the commit history behind ref (a
+// branch, tag or SHA; the default branch when empty). The caller stores the commits and builds
+// the timeline and contributors from them. GitHub's language stats only describe the present,
+// so for a ref they are left empty for the caller to compute from that tree.
+func (c *Client) FetchEverythingAt(ctx context.Context, owner, repoName, ref string) (*models.AnalyticsReport, []models.Commit, error) {
 	baseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repoName)
 	report := &models.AnalyticsReport{GeneratedAt: time.Now()}
 
+	// A fatal error in one goroutine cancels the others instead of letting the commit crawl run on
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
 	var err1, err2, err3 error
 
@@ -19,9 +32,10 @@ func (c *Client) FetchEverything(owner, repoName string) (*models.AnalyticsRepor
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err1 = c.get(baseURL, &report.RepoInfo)
+		err1 = c.get(ctx, baseURL, &report.RepoInfo)
 		if err1 != nil {
 			fmt.Printf("Error fetching repo metadata: %v\n", err1)
+			cancel()
 		}
 	}()
 
@@ -29,7 +43,11 @@ func (c *Client) FetchEverything(owner, repoName string) (*models.AnalyticsRepor
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err2 = c.get(baseURL+"/languages", &report.RepoInfo.Languages)
+		if ref != "" {
+			report.RepoInfo.Languages = make(map[string]int)
+			return
+		}
+		err2 = c.get(ctx, baseURL+"/languages", &report.RepoInfo.Languages)
 		if err2 != nil {
 			fmt.Printf("Error fetching repo languages: %v\n", err2)
 			// Don't fail the whole request if languages fail
@@ -39,95 +57,37 @@ func (c *Client) FetchEverything(owner, repoName string) (*models.AnalyticsRepor
 	}()
 
 	// 3. COMMITS (Fetch ALL commits with pagination)
+	var history []models.Commit
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		// A. Fetch raw list (paginated - fetches all commits)
-		rawCommits, err := c.FetchCommitsRaw(owner, repoName, "", "")
-		if err != nil {
-			fmt.Printf("Error fetching commits: %v\n", err)
-			err3 = err
+		history, err3 = c.FetchCommitHistory(ctx, owner, repoName, ref)
+		if err3 != nil {
+			fmt.Printf("Error fetching commits: %v\n", err3)
+			cancel()
 			return
 		}
 
-		fmt.Printf("Fetched %d commits\n", len(rawCommits))
-
-		// B. Process the data
-		statsMap := make(map[string]int)
-		avatars := make(map[string]string)
-		var timeline []time.Time // <--- NEW: Timeline slice
-
-		for _, commit := range rawCommits {
-			// --- Part 1: Extract Author (Existing logic) ---
-			var login string
-			var avatar string
-
-			if author, ok := commit["author"].(map[string]interface{}); ok && author != nil {
-				if loginVal, ok := author["login"].(string); ok {
-					login = loginVal
-				}
-				if avatarVal, ok := author["avatar_url"].(string); ok {
-					avatar = avatarVal
-				}
-			} else {
-				// Fallback to git metadata
-				if commitData, ok := commit["commit"].(map[string]interface{}); ok {
-					if commitAuthor, ok := commitData["author"].(map[string]interface{}); ok {
-						if nameVal, ok := commitAuthor["name"].(string); ok {
-							login = nameVal
-						}
-						avatar = ""
-					}
-				}
-			}
-
-			if login != "" {
-				statsMap[login]++
-				if avatar != "" {
-					avatars[login] = avatar
-				}
-			}
-
-			// --- Part 2: Extract Date for Heatmap (NEW) ---
-			if commitData, ok := commit["commit"].(map[string]interface{}); ok {
-				if authorData, ok := commitData["author"].(map[string]interface{}); ok {
-					if dateStr, ok := authorData["date"].(string); ok {
-						// Parse ISO 8601 / RFC3339 date (e.g. "2024-01-01T12:00:00Z")
-						if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
-							timeline = append(timeline, t)
-						}
-					}
-				}
-			}
-		}
+		fmt.Printf("Fetched %d commits\n", len(history))
 
-		// C. Save Data to Report
-		// Contributors
-		for login, count := range statsMap {
-			contrib := models.ContributorStats{
-				Total: count,
-			}
-			contrib.Author.Login = login
-			contrib.Author.AvatarURL = avatars[login]
-			report.Contributors = append(report.Contributors, contrib)
+		// Commits come newest first, so the first one is the HEAD (or ref) we analyzed
+		if len(history) > 0 {
+			report.CommitSHA = history[0].SHA
 		}
-
-		// Timeline
-		report.CommitTimeline = timeline // <--- NEW: Save timeline to report
 	}()
 
 	wg.Wait()
 
 	// Error handling...
 	if err1 != nil {
-		return nil, fmt.Errorf("metadata error: %w", err1)
+		return nil, nil, fmt.Errorf("metadata error: %w", err1)
 	}
 	if err2 != nil {
-		return nil, fmt.Errorf("language error: %w", err2)
+		return nil, nil, fmt.Errorf("language error: %w", err2)
 	}
 	if err3 != nil {
-		return nil, fmt.Errorf("commit fetch error: %w", err3)
+		return nil, nil, fmt.Errorf("commit fetch error: %w", err3)
 	}
 
 	report.RepoInfo.FullName = fmt.Sprintf("%s/%s", owner, repoName)
@@ -138,5 +98,5 @@ func (c *Client) FetchEverything(owner, repoName string) (*models.AnalyticsRepor
 		report.FileTypes = make(map[string]int)
 	}
 
-	return report, nil
+	return report, history, nil
 }
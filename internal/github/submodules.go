@@ -0,0 +1,184 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// maxSubmodules caps how many submodules IncludeSubmodules expands (one tree call each)
+const maxSubmodules = 20
+
+// Submodules returns the gitlink entries of the tree: submodules pinned to a commit SHA
+func (t *TreeResponse) Submodules() []TreeEntry {
+	var gitlinks []TreeEntry
+	for _, entry := range t.Tree {
+		if entry.Type == "commit" {
+			gitlinks = append(gitlinks, entry)
+		}
+	}
+	return gitlinks
+}
+
+// ParseGitmodules reads a .gitmodules file into submodule path -> URL
+func ParseGitmodules(content string) map[string]string {
+	urls := make(map[string]string)
+	var currentPath, currentURL string
+	flush := func() {
+		if currentPath != "" && currentURL != "" {
+			urls[strings.Trim(currentPath, "/")] = currentURL
+		}
+		currentPath, currentURL = "", ""
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "path":
+			currentPath = strings.Trim(strings.TrimSpace(value), `"`)
+		case "url":
+			currentURL = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	flush()
+	return urls
+}
+
+// FetchSubmodules lists the submodules of a tree with their URL from .gitmodules and the SHA
+// they are pinned to. Submodules hosted on GitHub (the only provider we can read) are Supported.
+func (c *Client) FetchSubmodules(ctx context.Context, owner, repo, ref string, tree *TreeResponse) ([]models.Submodule, error) {
+	gitlinks := tree.Submodules()
+	if len(gitlinks) == 0 {
+		return []models.Submodule{}, nil
+	}
+
+	urls := map[string]string{}
+	file, err := c.FetchFileAt(ctx, owner, repo, ref, ".gitmodules")
+	if err != nil {
+		fmt.Printf("Could not read .gitmodules of %s/%s: %v\n", owner, repo, err)
+	} else {
+		urls = ParseGitmodules(file.Content)
+	}
+
+	submodules := make([]models.Submodule, 0, len(gitlinks))
+	for _, entry := range gitlinks {
+		submodule := models.Submodule{Path: entry.Path, SHA: entry.SHA, URL: urls[entry.Path]}
+		submodule.Host, submodule.Owner, submodule.Repo = resolveSubmoduleURL(owner, repo, submodule.URL)
+		submodule.Supported = submodule.Host == "github.com" && submodule.Owner != "" && submodule.Repo != ""
+		submodules = append(submodules, submodule)
+	}
+	return submodules, nil
+}
+
+// IncludeSubmodules expands the supported submodules of a tree in place: their files are added
+// under the submodule path, as fetched at the pinned SHA. Only one level is expanded.
+func (c *Client) IncludeSubmodules(ctx context.Context, owner, repo, ref string, tree *TreeResponse) error {
+	submodules, err := c.FetchSubmodules(ctx, owner, repo, ref, tree)
+	if err != nil {
+		return err
+	}
+
+	expanded := 0
+	for i := range submodules {
+		submodule := &submodules[i]
+		if !submodule.Supported || expanded >= maxSubmodules {
+			continue
+		}
+		subtree, err := c.FetchTreeAt(ctx, submodule.Owner, submodule.Repo, submodule.SHA)
+		if err != nil {
+			fmt.Printf("Skipping submodule %s (%s/%s): %v\n", submodule.Path, submodule.Owner, submodule.Repo, err)
+			continue
+		}
+		expanded++
+		submodule.Included = true
+		for _, entry := range subtree.Tree {
+			if entry.Type == "commit" {
+				continue // nested submodules stay out
+			}
+			entry.Path = submodule.Path + "/" + entry.Path
+			tree.Tree = append(tree.Tree, entry)
+		}
+		tree.Truncated = tree.Truncated || subtree.Truncated
+	}
+
+	tree.SubmoduleInfo = submodules
+	return nil
+}
+
+// FileLocation is where a tree path actually lives: the repository itself, or a submodule
+type FileLocation struct {
+	Owner string
+	Repo  string
+	Ref   string
+	Path  string
+}
+
+// Locate maps a path of an expanded tree to the repository, ref and path to fetch it from
+func (t *TreeResponse) Locate(owner, repo, ref, filePath string) FileLocation {
+	for _, submodule := range t.SubmoduleInfo {
+		if submodule.Included && strings.HasPrefix(filePath, submodule.Path+"/") {
+			return FileLocation{
+				Owner: submodule.Owner,
+				Repo:  submodule.Repo,
+				Ref:   submodule.SHA,
+				Path:  strings.TrimPrefix(filePath, submodule.Path+"/"),
+			}
+		}
+	}
+	return FileLocation{Owner: owner, Repo: repo, Ref: ref, Path: filePath}
+}
+
+// resolveSubmoduleURL extracts host, owner and repo from a submodule URL. Relative URLs
+// ("../lib.git") are resolved against the parent repository on GitHub, as git does.
+func resolveSubmoduleURL(parentOwner, parentRepo, rawURL string) (string, string, string) {
+	if rawURL == "" {
+		return "", "", ""
+	}
+
+	var host, repoPath string
+	switch {
+	case strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../"):
+		host = "github.com"
+		repoPath = path.Join(parentOwner, parentRepo, rawURL)
+	case strings.Contains(rawURL, "://"):
+		// https://github.com/o/r.git, ssh://git@github.com/o/r, git://github.com/o/r
+		rest := rawURL[strings.Index(rawURL, "://")+3:]
+		host, repoPath, _ = strings.Cut(rest, "/")
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		host, _, _ = strings.Cut(host, ":")
+	default:
+		// scp-like git@github.com:o/r.git
+		var ok bool
+		host, repoPath, ok = strings.Cut(rawURL, ":")
+		if !ok {
+			return "", "", ""
+		}
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == ".." {
+		return strings.ToLower(host), "", ""
+	}
+	return strings.ToLower(host), parts[0], parts[1]
+}
@@ -1,16 +1,21 @@
 package github
 
 import (
-	"encoding/base64"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 )
 
 // TreeEntry represents a single file/folder in the repo tree
 type TreeEntry struct {
 	Path string `json:"path"`
 	Mode string `json:"mode"`
-	Type string `json:"type"` // "blob" for file, "tree" for directory
+	Type string `json:"type"` // "blob" for file, "tree" for directory, "commit" for a submodule
 	SHA  string `json:"sha"`
 	Size int    `json:"size,omitempty"`
 }
@@ -21,6 +26,9 @@ type TreeResponse struct {
 	URL       string      `json:"url"`
 	Tree      []TreeEntry `json:"tree"`
 	Truncated bool        `json:"truncated"`
+
+	// Set by IncludeSubmodules; Locate uses it to send submodule paths to their own repository
+	SubmoduleInfo []models.Submodule `json:"-"`
 }
 
 // FileContent represents the content of a single file
@@ -34,18 +42,15 @@ type FileContent struct {
 }
 
 // FetchRepoTree fetches the full file tree of a repository
-func (c *Client) FetchRepoTree(owner, repo string) (*TreeResponse, error) {
+func (c *Client) FetchRepoTree(ctx context.Context, owner, repo string) (*TreeResponse, error) {
 	// First try main, then master as fallback
 	branches := []string{"main", "master"}
 
 	var lastErr error
 	for _, branch := range branches {
-		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1", owner, repo, branch)
-
-		var tree TreeResponse
-		err := c.get(url, &tree)
+		tree, err := c.FetchTreeAt(ctx, owner, repo, branch)
 		if err == nil {
-			return &tree, nil
+			return tree, nil
 		}
 		lastErr = err
 	}
@@ -53,66 +58,194 @@ func (c *Client) FetchRepoTree(owner, repo string) (*TreeResponse, error) {
 	return nil, fmt.Errorf("failed to fetch tree from main or master: %w", lastErr)
 }
 
-// FetchFileContent fetches the content of a specific file
-func (c *Client) FetchFileContent(owner, repo, path string) (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, path)
+// FetchTreeAt fetches the full file tree at a branch, tag or commit SHA
+func (c *Client) FetchTreeAt(ctx context.Context, owner, repo, ref string) (*TreeResponse, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1", owner, repo, escapeRef(ref))
+
+	var tree TreeResponse
+	if err := c.get(ctx, url, &tree); err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+// ErrFileTooLarge is returned for files over the blobs API's 100 MB limit
+var ErrFileTooLarge = errors.New("file is too large to fetch")
+
+// FetchFileContent fetches the content of a specific file.
+// Binary files come back empty; use FetchFile to get their descriptor.
+func (c *Client) FetchFileContent(ctx context.Context, owner, repo, path string) (string, error) {
+	file, err := c.FetchFile(ctx, owner, repo, path)
+	if err != nil {
+		return "", err
+	}
+	return file.Content, nil
+}
+
+// FileFetchError explains why one path of a FetchMultipleFiles batch could not be fetched
+type FileFetchError struct {
+	Path   string
+	Reason string // "not_found", "too_large", "rate_limited", "forbidden", "canceled" or "request_failed"
+	Err    error
+}
 
-	var content FileContent
-	if err := c.get(url, &content); err != nil {
-		return "", fmt.Errorf("failed to fetch file content for %s: %w", path, err)
+func (e *FileFetchError) Error() string {
+	return fmt.Sprintf("%s: %s (%v)", e.Path, e.Reason, e.Err)
+}
+
+func (e *FileFetchError) Unwrap() error {
+	return e.Err
+}
+
+// newFileFetchError classifies a FetchFile failure
+func newFileFetchError(path string, err error) *FileFetchError {
+	reason := "request_failed"
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		reason = "canceled"
+	case errors.Is(err, ErrFileTooLarge):
+		reason = "too_large"
+	case errors.As(err, &apiErr) && apiErr.TooLarge:
+		reason = "too_large"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		reason = "not_found"
+	case errors.As(err, &apiErr) && apiErr.RateLimited:
+		reason = "rate_limited"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden:
+		reason = "forbidden"
 	}
+	return &FileFetchError{Path: path, Reason: reason, Err: err}
+}
 
-	// GitHub returns content as base64 encoded
-	if content.Encoding == "base64" {
-		decoded, err := base64.StdEncoding.DecodeString(content.Content)
-		if err != nil {
-			return "", fmt.Errorf("failed to decode base64 content: %w", err)
+// FileResult is the outcome of fetching one file: either Content and Descriptor, or Err is set
+type FileResult struct {
+	Path       string
+	Content    string
+	Descriptor ContentDescriptor
+	Err        *FileFetchError
+}
+
+// FileBatch holds FetchMultipleFiles results in the order the paths were requested
+type FileBatch struct {
+	Results []FileResult
+}
+
+// Fetched returns the files that were downloaded, in request order
+func (b *FileBatch) Fetched() []FileResult {
+	var fetched []FileResult
+	for _, result := range b.Results {
+		if result.Err == nil {
+			fetched = append(fetched, result)
 		}
-		return string(decoded), nil
 	}
+	return fetched
+}
 
-	return content.Content, nil
+// Contents returns the downloaded text files keyed by path, for callers that look files up by name
+func (b *FileBatch) Contents() map[string]string {
+	contents := make(map[string]string, len(b.Results))
+	for _, result := range b.Fetched() {
+		if !result.Descriptor.IsBinary {
+			contents[result.Path] = result.Content
+		}
+	}
+	return contents
 }
 
-// FetchMultipleFiles fetches content of multiple files concurrently
-func (c *Client) FetchMultipleFiles(owner, repo string, paths []string) (map[string]string, error) {
-	results := make(map[string]string)
-	errors := make(chan error, len(paths))
-	contents := make(chan struct {
-		path    string
-		content string
-	}, len(paths))
-
-	for _, path := range paths {
-		go func(p string) {
-			content, err := c.FetchFileContent(owner, repo, p)
-			if err != nil {
-				errors <- err
-				return
-			}
-			contents <- struct {
-				path    string
-				content string
-			}{p, content}
-		}(path)
+// Skipped lists the files that could not be fetched and why, ready to show to users
+func (b *FileBatch) Skipped() []models.SkippedFile {
+	skipped := []models.SkippedFile{}
+	for _, result := range b.Results {
+		if result.Err != nil {
+			skipped = append(skipped, models.SkippedFile{Path: result.Path, Reason: result.Err.Reason})
+		}
+	}
+	return skipped
+}
+
+// FetchMultipleFiles fetches the content of multiple files with a bounded worker pool.
+// Every path gets a result, in the order given; failed paths carry a *FileFetchError.
+// The error is only set when not a single file could be fetched.
+func (c *Client) FetchMultipleFiles(ctx context.Context, owner, repo string, paths []string) (*FileBatch, error) {
+	return c.FetchMultipleFilesAt(ctx, owner, repo, "", paths)
+}
+
+// FetchMultipleFilesAt is FetchMultipleFiles at a branch, tag or commit SHA (the default branch when empty)
+func (c *Client) FetchMultipleFilesAt(ctx context.Context, owner, repo, ref string, paths []string) (*FileBatch, error) {
+	return c.fetchFiles(ctx, paths, func(filePath string) FileLocation {
+		return FileLocation{Owner: owner, Repo: repo, Ref: ref, Path: filePath}
+	})
+}
+
+// FetchTreeFiles is FetchMultipleFilesAt for paths of a tree expanded with IncludeSubmodules:
+// files inside an included submodule are fetched from its repository at the pinned SHA
+func (c *Client) FetchTreeFiles(ctx context.Context, owner, repo, ref string, tree *TreeResponse, paths []string) (*FileBatch, error) {
+	return c.fetchFiles(ctx, paths, func(filePath string) FileLocation {
+		return tree.Locate(owner, repo, ref, filePath)
+	})
+}
+
+func (c *Client) fetchFiles(ctx context.Context, paths []string, locate func(filePath string) FileLocation) (*FileBatch, error) {
+	batch := &FileBatch{Results: make([]FileResult, len(paths))}
+	if len(paths) == 0 {
+		return batch, nil
 	}
 
-	// Collect results
-	for i := 0; i < len(paths); i++ {
+	workers := c.fetchConcurrency
+	if workers <= 0 {
+		workers = defaultFetchConcurrency
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	// Each worker writes only to the slots of the indexes it receives, so no locking is needed
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := FileResult{Path: paths[i]}
+				location := locate(paths[i])
+				file, err := c.FetchFileAt(ctx, location.Owner, location.Repo, location.Ref, location.Path)
+				if err != nil {
+					result.Err = newFileFetchError(paths[i], err)
+				} else {
+					result.Content = file.Content
+					result.Descriptor = file.Descriptor
+				}
+				batch.Results[i] = result
+			}
+		}()
+	}
+	// Stop handing out paths once the caller gives up; the rest are marked cancelled
+	queued := 0
+feed:
+	for ; queued < len(paths); queued++ {
 		select {
-		case err := <-errors:
-			// Log but don't fail completely
-			fmt.Printf("Warning: %v\n", err)
-		case result := <-contents:
-			results[result.path] = result.content
+		case jobs <- queued:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
+	for i := queued; i < len(paths); i++ {
+		batch.Results[i] = FileResult{Path: paths[i], Err: newFileFetchError(paths[i], ctx.Err())}
+	}
 
-	if len(results) == 0 {
-		return nil, fmt.Errorf("failed to fetch any files")
+	if err := ctx.Err(); err != nil {
+		return batch, fmt.Errorf("file fetch stopped: %w", err)
+	}
+	if len(batch.Fetched()) == 0 {
+		return batch, fmt.Errorf("failed to fetch any files: %w", batch.Results[0].Err)
 	}
 
-	return results, nil
+	return batch, nil
 }
 
 // GetTreeAsString converts the tree to a formatted string for AI analysis
@@ -121,33 +254,74 @@ func (t *TreeResponse) GetTreeAsString() string {
 	for _, entry := range t.Tree {
 		if entry.Type == "blob" {
 			result += entry.Path + "\n"
+		} else if entry.Type == "commit" {
+			result += entry.Path + " (submodule)\n"
 		}
 	}
 	return result
 }
 
+// BlobPaths returns the paths of every file (not folder) in the tree
+func (t *TreeResponse) BlobPaths() []string {
+	paths := make([]string, 0, len(t.Tree))
+	for _, entry := range t.Tree {
+		if entry.Type == "blob" {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
+
+// BlobSizes maps the path of every file in the tree to its size in bytes
+func (t *TreeResponse) BlobSizes() map[string]int {
+	sizes := make(map[string]int, len(t.Tree))
+	for _, entry := range t.Tree {
+		if entry.Type == "blob" {
+			sizes[entry.Path] = entry.Size
+		}
+	}
+	return sizes
+}
+
 // FileNode represents a node in the file tree structure (for frontend)
 type FileNode struct {
 	Name     string     `json:"name"`
 	Path     string     `json:"path"`
-	Type     string     `json:"type"` // "file" or "folder"
+	Type     string     `json:"type"`          // "file", "folder" or "submodule"
+	URL      string     `json:"url,omitempty"` // Submodules only: where it is hosted
+	SHA      string     `json:"sha,omitempty"` // Submodules only: the commit it is pinned to
 	Children []FileNode `json:"children,omitempty"`
 }
 
-// FetchFileTree fetches the complete file tree for a repository and returns it as a hierarchical structure
-func (c *Client) FetchFileTree(owner, repo string) ([]FileNode, error) {
+// FetchFileTree fetches the complete file tree for a repository and returns it as a hierarchical structure.
+// Submodules are always shown with their URL and pinned SHA; includeSubmodules also lists their files.
+func (c *Client) FetchFileTree(ctx context.Context, owner, repo string, includeSubmodules bool) ([]FileNode, error) {
 	// Use the existing FetchRepoTree method
-	treeResp, err := c.FetchRepoTree(owner, repo)
+	treeResp, err := c.FetchRepoTree(ctx, owner, repo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch tree: %w", err)
 	}
 
+	if includeSubmodules {
+		err = c.IncludeSubmodules(ctx, owner, repo, "", treeResp)
+	} else {
+		treeResp.SubmoduleInfo, err = c.FetchSubmodules(ctx, owner, repo, "", treeResp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch submodules: %w", err)
+	}
+
 	// Build the hierarchical tree structure
-	return buildFileTree(treeResp.Tree), nil
+	return buildFileTree(treeResp.Tree, treeResp.SubmoduleInfo), nil
 }
 
 // buildFileTree converts flat GitHub tree entries into a hierarchical structure
-func buildFileTree(entries []TreeEntry) []FileNode {
+func buildFileTree(entries []TreeEntry, submodules []models.Submodule) []FileNode {
+	submoduleURLs := make(map[string]string, len(submodules))
+	for _, submodule := range submodules {
+		submoduleURLs[submodule.Path] = submodule.URL
+	}
+
 	// Create a map to store nodes by path (using pointers)
 	nodeMap := make(map[string]*FileNode)
 
@@ -165,6 +339,8 @@ func buildFileTree(entries []TreeEntry) []FileNode {
 		nodeType := "file"
 		if entry.Type == "tree" {
 			nodeType = "folder"
+		} else if entry.Type == "commit" {
+			nodeType = "submodule"
 		}
 
 		// Get the name from the path
@@ -181,6 +357,10 @@ func buildFileTree(entries []TreeEntry) []FileNode {
 		if nodeType == "folder" {
 			node.Children = []FileNode{}
 		}
+		if nodeType == "submodule" {
+			node.URL = submoduleURLs[entry.Path]
+			node.SHA = entry.SHA
+		}
 
 		nodeMap[entry.Path] = node
 	}
@@ -226,6 +406,15 @@ func buildTreeRecursive(nodes []FileNode, nodeMap map[string]*FileNode) []FileNo
 			Name: node.Name,
 			Path: node.Path,
 			Type: node.Type,
+			URL:  node.URL,
+			SHA:  node.SHA,
+		}
+
+		// An included submodule has children like a folder; otherwise it stays a leaf
+		if node.Type == "submodule" {
+			if mapNode, exists := nodeMap[node.Path]; exists && len(mapNode.Children) > 0 {
+				newNode.Children = buildTreeRecursive(mapNode.Children, nodeMap)
+			}
 		}
 
 		if node.Type == "folder" {
@@ -240,28 +429,33 @@ func buildTreeRecursive(nodes []FileNode, nodeMap map[string]*FileNode) []FileNo
 		result = append(result, newNode)
 	}
 
-	// Sort: folders first, then files, both alphabetically
+	// Sort: folders and submodules first, then files, both alphabetically
 	sortFileNodes(result)
 
 	return result
 }
 
-// sortFileNodes sorts file nodes: folders first, then files, both alphabetically
+// sortFileNodes sorts file nodes: folders and submodules first, then files, both alphabetically
 func sortFileNodes(nodes []FileNode) {
 	// Sort current level
 	for i := 0; i < len(nodes); i++ {
 		for j := i + 1; j < len(nodes); j++ {
 			// Folders come before files
-			if nodes[j].Type == "folder" && nodes[i].Type == "file" {
+			if isFolderNode(nodes[j]) && !isFolderNode(nodes[i]) {
 				nodes[i], nodes[j] = nodes[j], nodes[i]
-			} else if nodes[i].Type == nodes[j].Type && nodes[i].Name > nodes[j].Name {
-				// Same type, sort alphabetically
+			} else if isFolderNode(nodes[i]) == isFolderNode(nodes[j]) && nodes[i].Name > nodes[j].Name {
+				// Same kind, sort alphabetically
 				nodes[i], nodes[j] = nodes[j], nodes[i]
 			}
 		}
-		// Recursively sort children
-		if nodes[i].Type == "folder" && len(nodes[i].Children) > 0 {
+		// Recursively sort children, including those of expanded submodules
+		if isFolderNode(nodes[i]) && len(nodes[i].Children) > 0 {
 			sortFileNodes(nodes[i].Children)
 		}
 	}
 }
+
+// isFolderNode reports whether a node can hold children: a folder or a submodule
+func isFolderNode(node FileNode) bool {
+	return node.Type == "folder" || node.Type == "submodule"
+}
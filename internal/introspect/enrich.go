@@ -0,0 +1,278 @@
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/analysis"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/github"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// sourceSampleSize is how many source files we download for header/content checks
+const sourceSampleSize = 40
+
+// historyScanDepth is how many recent commits are diffed when history scanning is on
+const historyScanDepth = 30
+
+// maxProtectedHeads is how many protected branches outside our history get their head checked
+const maxProtectedHeads = 10
+
+// maxPackageHistories is how many monorepo packages get their own commit history (one
+// listing each), and packageHistoryDepth how many commits each listing goes back
+const (
+	maxPackageHistories = 25
+	packageHistoryDepth = 300
+)
+
+// enrichOptions are the per-request switches for the optional, more expensive analyzers
+type enrichOptions struct {
+	ScanHistory       bool
+	Ref               string // Commit to analyze instead of the default branch head
+	IncludeSubmodules bool   // Analyze the files of GitHub-hosted submodules as part of the tree
+
+	Snapshot    *github.ResolvedCommit // The past commit a snapshot is built from; nil for the live report
+	SnapshotRef string                 // The ref or as-of date that was asked for
+}
+
+// enrichReport runs the deterministic, tree-based analyzers on a freshly fetched report.
+// It fails when the tree, the file contents or the requested history can't be read, or when
+// ctx ends first: an empty inventory or secret scan would look like a clean result. Smaller
+// extras (submodules, protected branches, package histories) are logged and left out.
+func (h *Handler) enrichReport(ctx context.Context, owner, repoName string, report *models.AnalyticsReport, opts enrichOptions) error {
+	report.Dependencies = analysis.BuildDependencyInventory(nil)
+	report.Licenses = analysis.BuildLicenseReport(report.RepoInfo.License, nil, nil, report.Dependencies)
+	report.Secrets = analysis.BuildSecretScan(nil, nil, nil, 0)
+	report.Vulnerabilities = analysis.MatchVulnerabilities(nil, report.Dependencies)
+	report.CI = analysis.BuildCIReport(nil)
+	report.Testing = analysis.BuildTestReport(nil, nil, report.Dependencies)
+	report.Workspace = analysis.DetectWorkspace(nil, nil)
+	report.Submodules = []models.Submodule{}
+	report.Health = analysis.BuildHealthReport(nil, report.RepoInfo)
+
+	// Working hours only need the commits we already have
+	report.WorkingHours = analysis.BuildWorkingHours(report.Commits)
+
+	// Commit signatures, with protected branches checked for unverified commits
+	protected, checked := h.protectedBranchCommits(ctx, owner, repoName, report)
+	report.Signatures = analysis.BuildSignatureReport(report.Commits, protected)
+	report.Signatures.ProtectedBranchesChecked = checked
+
+	// Every analyzer reads the commit the report describes: the snapshot's, or the head the
+	// commits were fetched at, whatever the default branch is called
+	ref := opts.Ref
+	if ref == "" {
+		ref = report.CommitSHA
+	}
+	if ref == "" {
+		ref = report.RepoInfo.DefaultBranch
+	}
+
+	var tree *github.TreeResponse
+	var err error
+	if ref != "" {
+		tree, err = h.githubClient.FetchTreeAt(ctx, owner, repoName, ref)
+	} else {
+		tree, err = h.githubClient.FetchRepoTree(ctx, owner, repoName)
+	}
+	if err != nil {
+		return fmt.Errorf("fetching tree of %s/%s: %w", owner, repoName, err)
+	}
+
+	// Submodules are always listed; expanded ones join the tree for every analyzer
+	if opts.IncludeSubmodules {
+		err = h.githubClient.IncludeSubmodules(ctx, owner, repoName, ref, tree)
+	} else {
+		tree.SubmoduleInfo, err = h.githubClient.FetchSubmodules(ctx, owner, repoName, ref, tree)
+	}
+	if err != nil {
+		fmt.Printf("Error reading submodules of %s/%s: %v\n", owner, repoName, err)
+	} else {
+		report.Submodules = tree.SubmoduleInfo
+	}
+	paths := tree.BlobPaths()
+
+	// GitHub's language stats are for today's tree and leave submodules out;
+	// a snapshot counts its own, and expanded submodules are added on top
+	if opts.Ref != "" {
+		report.RepoInfo.Languages = analysis.LanguageBytes(tree.BlobSizes())
+		report.FileTypes = report.RepoInfo.Languages
+	} else if opts.IncludeSubmodules {
+		if report.RepoInfo.Languages == nil {
+			report.RepoInfo.Languages = make(map[string]int)
+		}
+		for language, bytes := range analysis.LanguageBytes(submoduleSizes(tree)) {
+			report.RepoInfo.Languages[language] += bytes
+		}
+		report.FileTypes = report.RepoInfo.Languages
+	}
+
+	manifests := analysis.FindManifests(paths)
+	licenseFiles := analysis.FindLicenseFiles(paths)
+	sourceSample := analysis.SelectSourceSample(paths, sourceSampleSize)
+	secretCandidates := analysis.FindSecretCandidates(paths)
+	ciConfigs := analysis.FindCIConfigs(paths)
+	workspaceConfigs := analysis.FindWorkspaceConfigs(paths)
+
+	// One batch for every analyzer that needs file contents
+	var wanted []string
+	wanted = append(wanted, manifests...)
+	wanted = append(wanted, licenseFiles...)
+	wanted = append(wanted, sourceSample...)
+	wanted = append(wanted, secretCandidates...)
+	wanted = append(wanted, ciConfigs...)
+	wanted = append(wanted, workspaceConfigs...)
+
+	files := map[string]string{}
+	if len(wanted) > 0 {
+		fmt.Printf("Fetching %d files for analysis (%d manifests, %d license files)...\n", len(wanted), len(manifests), len(licenseFiles))
+		batch, err := h.githubClient.FetchTreeFiles(ctx, owner, repoName, ref, tree, wanted)
+		if err != nil {
+			return fmt.Errorf("fetching files for analysis: %w", err)
+		}
+		files = batch.Contents()
+		if skipped := batch.Skipped(); len(skipped) > 0 {
+			fmt.Printf("Skipped %d files during analysis (first: %s, %s)\n", len(skipped), skipped[0].Path, skipped[0].Reason)
+		}
+	}
+
+	// Dependencies
+	report.Dependencies = analysis.BuildDependencyInventory(subset(files, manifests))
+
+	// Known vulnerabilities, matched against the local OSV snapshot
+	report.Vulnerabilities = analysis.MatchVulnerabilities(h.vulnDB, report.Dependencies)
+
+	// CI/CD pipelines
+	report.CI = analysis.BuildCIReport(subset(files, ciConfigs))
+
+	// Tests - sizes from the tree, exact line counts where we fetched the file
+	report.Testing = analysis.BuildTestReport(tree.BlobSizes(), files, report.Dependencies)
+
+	// Community health checklist
+	report.Health = analysis.BuildHealthReport(paths, report.RepoInfo)
+
+	// Monorepo packages, each with its own slice of the tree and history
+	report.Workspace = analysis.DetectWorkspace(tree.BlobSizes(), files)
+	analysis.ScopePackages(&report.Workspace, tree.BlobSizes(), files, report.Dependencies)
+	h.applyPackageHistories(ctx, owner, repoName, report, ref)
+
+	// Licenses
+	report.Licenses = analysis.BuildLicenseReport(report.RepoInfo.License, subset(files, licenseFiles), subset(files, sourceSample), report.Dependencies)
+
+	// Secrets - every blob we downloaded, plus recent history when asked for
+	var historyFindings []models.SecretFinding
+	commitsScanned := 0
+	if opts.ScanHistory {
+		fmt.Printf("Scanning the last %d commits for secrets...\n", historyScanDepth)
+		diffs, err := h.githubClient.FetchRecentCommitDiffs(ctx, owner, repoName, ref, historyScanDepth)
+		if err != nil {
+			return fmt.Errorf("fetching commit history for the secret scan: %w", err)
+		}
+		for _, diff := range diffs {
+			for _, file := range diff.Files {
+				historyFindings = append(historyFindings, analysis.ScanPatchForSecrets(diff.SHA, file.Filename, file.Patch)...)
+			}
+		}
+		commitsScanned = len(diffs)
+	}
+	report.Secrets = analysis.BuildSecretScan(paths, files, historyFindings, commitsScanned)
+
+	// Sections built after a timeout may have been cut short
+	return ctx.Err()
+}
+
+// protectedBranchCommits maps each protected branch to the commits of it we can check: the
+// whole fetched history for the default branch, and the head commit for the others. GitHub
+// only knows today's protection rules and branch heads, which say nothing about a snapshot's
+// past commit, so snapshots are not checked. The bool reports whether the check ran.
+func (h *Handler) protectedBranchCommits(ctx context.Context, owner, repoName string, report *models.AnalyticsReport) (map[string][]models.CommitActivity, bool) {
+	protected := make(map[string][]models.CommitActivity)
+	if report.Snapshot {
+		return protected, false
+	}
+	branches, err := h.githubClient.FetchProtectedBranches(ctx, owner, repoName)
+	if err != nil {
+		fmt.Printf("Error fetching protected branches of %s/%s: %v\n", owner, repoName, err)
+		return protected, false
+	}
+
+	known := make(map[string]models.CommitActivity, len(report.Commits))
+	for _, commit := range report.Commits {
+		known[commit.SHA] = commit
+	}
+
+	checked := 0
+	for _, branch := range branches {
+		if branch.Name == report.RepoInfo.DefaultBranch {
+			protected[branch.Name] = report.Commits
+			continue
+		}
+		if commit, ok := known[branch.SHA]; ok {
+			protected[branch.Name] = []models.CommitActivity{commit}
+			continue
+		}
+		if checked >= maxProtectedHeads || ctx.Err() != nil {
+			continue
+		}
+		checked++
+		head, err := h.githubClient.FetchCommit(ctx, owner, repoName, branch.SHA)
+		if err != nil {
+			fmt.Printf("Error fetching head of protected branch %s: %v\n", branch.Name, err)
+			continue
+		}
+		protected[branch.Name] = []models.CommitActivity{*head}
+	}
+	return protected, true
+}
+
+// applyPackageHistories lists the commits touching each monorepo package. The root package
+// has no path of its own to filter on, so it takes the whole history.
+func (h *Handler) applyPackageHistories(ctx context.Context, owner, repoName string, report *models.AnalyticsReport, ref string) {
+	asOf := report.GeneratedAt
+	if report.SnapshotDate != nil {
+		asOf = *report.SnapshotDate
+	}
+
+	for i := range report.Workspace.Packages {
+		pkg := &report.Workspace.Packages[i]
+		if pkg.Path == "." {
+			analysis.ApplyPackageHistory(pkg, report.Commits, asOf)
+			continue
+		}
+		if i >= maxPackageHistories || ctx.Err() != nil {
+			continue
+		}
+		commits, err := h.githubClient.FetchPathCommits(ctx, owner, repoName, ref, pkg.Path, packageHistoryDepth)
+		if err != nil {
+			fmt.Printf("Error fetching history of package %s: %v\n", pkg.Path, err)
+			continue
+		}
+		analysis.ApplyPackageHistory(pkg, commits, asOf)
+	}
+}
+
+// submoduleSizes are the blob sizes of the files that came from expanded submodules
+func submoduleSizes(tree *github.TreeResponse) map[string]int {
+	sizes := make(map[string]int)
+	for p, size := range tree.BlobSizes() {
+		for _, submodule := range tree.SubmoduleInfo {
+			if submodule.Included && strings.HasPrefix(p, submodule.Path+"/") {
+				sizes[p] = size
+				break
+			}
+		}
+	}
+	return sizes
+}
+
+// subset picks the fetched contents of the given paths
+func subset(files map[string]string, paths []string) map[string]string {
+	result := make(map[string]string, len(paths))
+	for _, p := range paths {
+		if content, ok := files[p]; ok {
+			result[p] = content
+		}
+	}
+	return result
+}
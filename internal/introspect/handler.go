@@ -1,13 +1,21 @@
 package introspect
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/ai"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/analysis"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/github"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 )
 
 type Handler struct {
@@ -15,24 +23,61 @@ type Handler struct {
 	githubClient     *github.Client
 	geminiClient     *ai.GeminiClient
 	elevenLabsClient *ai.ElevenLabsClient
+	vulnDB           *analysis.VulnDatabase // nil when no OSV snapshot is configured
+	cacheTTL         time.Duration          // Cached reports older than this are refreshed in the background; 0 never
+
+	refreshMu  sync.Mutex
+	refreshing map[string]bool // Repos (and build options) with a background refresh in flight
 }
 
-func NewHandler(repo *ReportRepository, githubClient *github.Client, geminiClient *ai.GeminiClient, elevenLabsClient *ai.ElevenLabsClient) *Handler {
+// DefaultReportCacheTTL is how long a cached report counts as fresh when REPORT_CACHE_TTL is unset
+const DefaultReportCacheTTL = 24 * time.Hour
+
+func NewHandler(repo *ReportRepository, githubClient *github.Client, geminiClient *ai.GeminiClient, elevenLabsClient *ai.ElevenLabsClient, vulnDB *analysis.VulnDatabase, cacheTTL time.Duration) *Handler {
 	return &Handler{
 		repo:             repo,
 		githubClient:     githubClient,
 		geminiClient:     geminiClient,
 		elevenLabsClient: elevenLabsClient,
+		vulnDB:           vulnDB,
+		cacheTTL:         cacheTTL,
+		refreshing:       make(map[string]bool),
 	}
 }
 
+// Per-stage deadlines. Each stage also stops as soon as the client disconnects.
+const (
+	fetchStageTimeout  = 3 * time.Minute // GitHub metadata and the full commit crawl
+	enrichStageTimeout = 2 * time.Minute // Tree analyzers, file downloads and history scan
+	chatStageTimeout   = 90 * time.Second
+	speechStageTimeout = 30 * time.Second
+)
+
+// statusForError maps a cancelled or timed-out stage to 504 instead of a generic 500
+func statusForError(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
 type AnalyzeRequest struct {
-	RepoURL string `json:"repo_url" binding:"required,url"`
+	RepoURL     string `json:"repo_url" binding:"required,url"`
+	ScanHistory bool   `json:"scan_history"` // Also scan recent commit diffs for secrets
+	Ref         string `json:"ref"`          // Branch, tag or SHA to analyze instead of the default branch head
+	AsOf        string `json:"as_of"`        // Date (YYYY-MM-DD or RFC 3339): analyze the last commit before it
+
+	IncludeSubmodules bool `json:"include_submodules"` // Analyze GitHub-hosted submodules as part of the repo
+
+	Refresh bool `json:"refresh"` // Rebuild even when a cached report exists (also ?refresh=true)
+	MaxAge  *int `json:"max_age"` // Seconds: rebuild when the cached report is older (also ?max_age=)
 }
 
 type SmartSummaryRequest struct {
-	Owner string `json:"owner" binding:"required"`
-	Repo  string `json:"repo" binding:"required"`
+	Owner             string `json:"owner" binding:"required"`
+	Repo              string `json:"repo" binding:"required"`
+	IncludeSubmodules bool   `json:"include_submodules"`
+	Refresh           bool   `json:"refresh"` // Regenerate even when the tree already has a stored summary (also ?refresh=true)
 }
 
 // AnalyzeRepo handles the analysis of a repository
@@ -51,44 +96,500 @@ func (h *Handler) AnalyzeRepo(c *gin.Context) {
 
 	fullName := owner + "/" + repoName
 
-	// Check cache first
-	existingReport, err := h.repo.GetReportByRepoName(fullName)
-	if err == nil {
-		fmt.Println("Returning cached report for", fullName)
-		c.JSON(http.StatusOK, existingReport)
+	if req.Ref != "" && req.AsOf != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either ref or as_of, not both"})
 		return
 	}
+	var asOf time.Time
+	if req.AsOf != "" {
+		if asOf, err = parseAsOf(req.AsOf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"})
+			return
+		}
+	}
 
-	// Fetch fresh data (fetches ALL commits with pagination)
-	fmt.Println("Fetching fresh data for", fullName)
-	report, err := h.githubClient.FetchEverything(owner, repoName)
+	// refresh always rebuilds; max_age rebuilds only a cached report older than it (-1: not given)
+	refresh := req.Refresh || c.Query("refresh") == "true"
+	maxAge := time.Duration(-1)
+	if req.MaxAge != nil {
+		if *req.MaxAge < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_age must be a number of seconds"})
+			return
+		}
+		maxAge = time.Duration(*req.MaxAge) * time.Second
+	}
+	if value := c.Query("max_age"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_age must be a number of seconds"})
+			return
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+
+	// The request context stops everything if the browser goes away
+	resolveCtx, cancelResolve := context.WithTimeout(c.Request.Context(), fetchStageTimeout)
+	defer cancelResolve()
+
+	// A ref or as-of date pins the analysis to one past commit, stored as its own snapshot
+	var snapshot *github.ResolvedCommit
+	switch {
+	case req.Ref != "":
+		snapshot, err = h.githubClient.ResolveRef(resolveCtx, owner, repoName, req.Ref)
+	case req.AsOf != "":
+		snapshot, err = h.githubClient.ResolveCommitAsOf(resolveCtx, owner, repoName, asOf)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var apiErr *github.APIError
+		if errors.Is(err, github.ErrNoCommits) || errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusUnprocessableEntity) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	ref := ""
+	if snapshot != nil {
+		ref = snapshot.SHA
+	}
+	opts := enrichOptions{ScanHistory: req.ScanHistory, Ref: ref, IncludeSubmodules: req.IncludeSubmodules, Snapshot: snapshot, SnapshotRef: req.Ref + req.AsOf}
+
+	// Check cache first; only a report built with the requested options counts
+	existingReport, err := h.repo.GetCachedReport(fullName, ref, opts.ScanHistory, opts.IncludeSubmodules)
+	if err == nil {
+		age := time.Since(existingReport.GeneratedAt)
+		switch {
+		case snapshot != nil:
+			// A past revision never changes, so its snapshot never goes stale
+			existingReport.Cache = h.cacheInfo(existingReport, "hit")
+			c.JSON(http.StatusOK, existingReport)
+			return
+		case refresh:
+			fmt.Println("Refresh requested for", fullName)
+		case maxAge >= 0 && age > maxAge:
+			fmt.Printf("Cached report of %s is %s old, past the requested max_age\n", fullName, age.Round(time.Second))
+		case h.cacheTTL > 0 && age > h.cacheTTL:
+			// Stale-while-revalidate: answer now, build the new report for the next visitor
+			fmt.Println("Returning stale report for", fullName)
+			// Rebuilt the way it was built, whoever happened to find it stale
+			h.refreshInBackground(owner, repoName, enrichOptions{
+				ScanHistory:       existingReport.ScanHistory,
+				IncludeSubmodules: existingReport.IncludeSubmodules,
+			})
+			existingReport.Cache = h.cacheInfo(existingReport, "stale")
+			existingReport.Cache.Revalidating = true
+			c.JSON(http.StatusOK, existingReport)
+			return
+		default:
+			fmt.Println("Returning cached report for", fullName)
+			existingReport.Cache = h.cacheInfo(existingReport, "hit")
+			c.JSON(http.StatusOK, existingReport)
+			return
+		}
+	}
+
+	if snapshot != nil {
+		fmt.Printf("Fetching snapshot of %s at %s\n", fullName, ref)
+	} else {
+		fmt.Println("Fetching fresh data for", fullName)
+	}
+	report, err := h.buildReport(c.Request.Context(), owner, repoName, opts)
+	if err != nil {
+		// A half-enriched report must not end up in the cache
+		if c.Request.Context().Err() != nil {
+			fmt.Printf("Analysis of %s abandoned: %v\n", fullName, err)
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
 	// Save to DB
 	if err := h.repo.SaveReport(report); err != nil {
 		fmt.Println("Error saving to DB:", err)
 	}
 
+	report.Cache = h.cacheInfo(report, "miss")
 	c.JSON(http.StatusOK, report)
 }
 
+// buildReport fetches and analyzes a repository, each stage under its own deadline.
+// It fails when ctx is cancelled or any stage can't finish, so a half-enriched report is
+// never returned.
+func (h *Handler) buildReport(ctx context.Context, owner, repoName string, opts enrichOptions) (*models.AnalyticsReport, error) {
+	// Fetch fresh data (fetches ALL commits with pagination)
+	fetchCtx, cancelFetch := context.WithTimeout(ctx, fetchStageTimeout)
+	report, history, err := h.githubClient.FetchEverythingAt(fetchCtx, owner, repoName, opts.Ref)
+	cancelFetch()
+	if err != nil {
+		return nil, err
+	}
+
+	// Contributors who asked to be erased stay out of new analyses too, as authors and in
+	// messages (e.g. a Co-authored-by trailer of someone else's commit)
+	erased, err := h.erasedIdentities()
+	if err != nil {
+		return nil, err
+	}
+	if len(erased) > 0 {
+		for i := range history {
+			analysis.EraseCommitAuthor(&history[i], erased)
+			history[i].Message = analysis.RedactErased(history[i].Message, erased)
+		}
+	}
+
+	// Commits go to the commits table first; the timeline and contributors are built from it
+	analysis.ApplyCommitHistory(report, h.storeCommits(report.RepoInfo.FullName, history))
+
+	report.ScanHistory = opts.ScanHistory
+	report.IncludeSubmodules = opts.IncludeSubmodules
+
+	// The analyzers look at these: a snapshot's protected branches and package activity
+	// are judged as of its commit, not as of today
+	if opts.Snapshot != nil {
+		report.Snapshot = true
+		report.SnapshotRef = opts.SnapshotRef
+		report.SnapshotDate = &opts.Snapshot.Date
+		report.CommitSHA = opts.Snapshot.SHA
+	}
+
+	// Deterministic analysis of the repository contents
+	enrichCtx, cancelEnrich := context.WithTimeout(ctx, enrichStageTimeout)
+	err = h.enrichReport(enrichCtx, owner, repoName, report, opts)
+	cancelEnrich()
+	if err != nil {
+		return nil, err
+	}
+	if len(erased) > 0 {
+		// Package histories and protected branch commits were fetched straight from GitHub
+		analysis.EraseContributor(report, erased)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// storeCommits saves a fetched history and reads it back from the commits table. If the
+// database fails, the fetched rows are used as they are so the analysis still completes.
+func (h *Handler) storeCommits(fullName string, history []models.Commit) []models.Commit {
+	if err := h.repo.SaveCommits(history); err != nil {
+		fmt.Println("Error saving commits:", err)
+		return history
+	}
+
+	shas := make([]string, 0, len(history))
+	for _, commit := range history {
+		shas = append(shas, commit.SHA)
+	}
+	stored, err := h.repo.GetCommits(fullName, shas)
+	if err != nil {
+		fmt.Println("Error loading commits:", err)
+		return history
+	}
+	return stored
+}
+
+// refreshInBackground rebuilds and stores a repo's report unless a refresh is already running
+func (h *Handler) refreshInBackground(owner, repoName string, opts enrichOptions) {
+	fullName := owner + "/" + repoName
+	key := fmt.Sprintf("%s scan_history=%t submodules=%t", fullName, opts.ScanHistory, opts.IncludeSubmodules)
+
+	h.refreshMu.Lock()
+	if h.refreshing[key] {
+		h.refreshMu.Unlock()
+		return
+	}
+	h.refreshing[key] = true
+	h.refreshMu.Unlock()
+
+	go func() {
+		defer func() {
+			h.refreshMu.Lock()
+			delete(h.refreshing, key)
+			h.refreshMu.Unlock()
+		}()
+
+		// Not tied to the request: the visitor already has the stale report
+		report, err := h.buildReport(context.Background(), owner, repoName, opts)
+		if err != nil {
+			fmt.Printf("Background refresh of %s failed: %v\n", fullName, err)
+			return
+		}
+		if err := h.repo.SaveReport(report); err != nil {
+			fmt.Println("Error saving to DB:", err)
+			return
+		}
+		fmt.Println("Background refresh of", fullName, "done")
+	}()
+}
+
+// cacheInfo describes how old a served report is
+func (h *Handler) cacheInfo(report *models.AnalyticsReport, status string) *models.CacheInfo {
+	return &models.CacheInfo{
+		Status:      status,
+		AgeSeconds:  int(time.Since(report.GeneratedAt).Seconds()),
+		GeneratedAt: report.GeneratedAt,
+		SourceSHA:   report.CommitSHA,
+		TTLSeconds:  int(h.cacheTTL.Seconds()),
+	}
+}
+
+// parseAsOf reads an as-of date; a bare date means the end of that day in UTC
+func parseAsOf(value string) (time.Time, error) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 func (h *Handler) GetReport(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 	fullName := owner + "/" + repoName
 
-	report, err := h.repo.GetReportByRepoName(fullName)
+	// ?commit=<sha> returns a stored snapshot instead of the live report
+	var report *models.AnalyticsReport
+	var err error
+	if commit := c.Query("commit"); commit != "" {
+		report, err = h.repo.GetSnapshot(fullName, commit)
+	} else {
+		report, err = h.repo.GetReportByRepoName(fullName)
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
 		return
 	}
 
+	// ?package=services/api returns just that monorepo package's slice of the report
+	if packagePath := c.Query("package"); packagePath != "" {
+		for _, pkg := range report.Workspace.Packages {
+			if pkg.Path == strings.Trim(packagePath, "/") || pkg.Name == packagePath {
+				c.JSON(http.StatusOK, pkg)
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found in this report"})
+		return
+	}
+
+	// ?severity=high keeps only vulnerabilities at or above that level
+	if severity := c.Query("severity"); severity != "" {
+		minimum := analysis.SeverityRank(severity)
+		if minimum == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "severity must be one of critical, high, medium, low"})
+			return
+		}
+		filtered := []models.Vulnerability{}
+		for _, finding := range report.Vulnerabilities.Findings {
+			if analysis.SeverityRank(finding.Severity) >= minimum {
+				filtered = append(filtered, finding)
+			}
+		}
+		report.Vulnerabilities.Findings = filtered
+	}
+
 	c.JSON(http.StatusOK, report)
 }
 
+// GetDependencies returns the dependency inventory of an analyzed repository
+func (h *Handler) GetDependencies(c *gin.Context) {
+	fullName := c.Param("owner") + "/" + c.Param("repo")
+
+	report, err := h.repo.GetReportByRepoName(fullName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report.Dependencies)
+}
+
+// GetSBOM exports the component inventory of an analyzed repository.
+// ?format=cyclonedx (default) returns CycloneDX 1.5 JSON, ?format=spdx returns SPDX 2.3 JSON.
+func (h *Handler) GetSBOM(c *gin.Context) {
+	fullName := c.Param("owner") + "/" + c.Param("repo")
+
+	report, err := h.repo.GetReportByRepoName(fullName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "cyclonedx") {
+	case "cyclonedx":
+		c.Header("Content-Type", "application/vnd.cyclonedx+json")
+		c.JSON(http.StatusOK, analysis.BuildCycloneDX(report))
+	case "spdx":
+		c.Header("Content-Type", "application/spdx+json")
+		c.JSON(http.StatusOK, analysis.BuildSPDX(report))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format. Use cyclonedx or spdx"})
+	}
+}
+
+// GetContributors totals the stored commits of a repository per author, with line stats.
+// ?since= and ?until= (YYYY-MM-DD) limit it to commits authored in that range.
+func (h *Handler) GetContributors(c *gin.Context) {
+	fullName := c.Param("owner") + "/" + c.Param("repo")
+
+	var since, until *time.Time
+	if value := c.Query("since"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a date (YYYY-MM-DD)"})
+			return
+		}
+		since = &day
+	}
+	if value := c.Query("until"); value != "" {
+		day, err := parseAsOf(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be a date (YYYY-MM-DD)"})
+			return
+		}
+		until = &day
+	}
+
+	activity, err := h.repo.ContributorActivity(fullName, since, until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(activity) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no commits stored for this repository"})
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// GetHistory lists every stored analysis of a repository, newest first
+func (h *Handler) GetHistory(c *gin.Context) {
+	fullName := c.Param("owner") + "/" + c.Param("repo")
+
+	reports, err := h.repo.ListReports(fullName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(reports) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+		return
+	}
+
+	history := make([]models.ReportSnapshot, 0, len(reports))
+	for i := range reports {
+		history = append(history, analysis.SummarizeReport(&reports[i]))
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// DiffReports shows how a repository changed between two stored analyses.
+// ?from= and ?to= take report IDs from the history; without them the newest
+// analysis is compared with the one before it.
+func (h *Handler) DiffReports(c *gin.Context) {
+	fullName := c.Param("owner") + "/" + c.Param("repo")
+
+	fromID, err := parseReportID(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a report id"})
+		return
+	}
+	toID, err := parseReportID(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a report id"})
+		return
+	}
+
+	if fromID == 0 || toID == 0 {
+		reports, err := h.repo.ListReports(fullName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// History is newest first: "to" defaults to the newest, "from" to the one before "to"
+		for i, report := range reports {
+			if toID == 0 {
+				toID = report.ID
+			}
+			if report.ID == toID && fromID == 0 && i+1 < len(reports) {
+				fromID = reports[i+1].ID
+			}
+		}
+		if fromID == 0 || toID == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "need at least two stored analyses to diff"})
+			return
+		}
+	}
+
+	from, err := h.repo.GetReportByID(fullName, fromID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("report %d not found", fromID)})
+		return
+	}
+	to, err := h.repo.GetReportByID(fullName, toID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("report %d not found", toID)})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis.DiffReports(from, to))
+}
+
+// parseReportID reads an optional report id; 0 means it was not given
+func parseReportID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid report id %q", value)
+	}
+	return uint(id), nil
+}
+
+// CompareRefs answers "what changed between v1.2 and v1.3": commits, changed files,
+// contributors and the language and size deltas between the two trees.
+// ?base= and ?head= take tags, branches or commit SHAs.
+func (h *Handler) CompareRefs(c *gin.Context) {
+	owner, repoName := c.Param("owner"), c.Param("repo")
+	base, head := c.Query("base"), c.Query("head")
+	if base == "" || head == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both base and head refs are required"})
+		return
+	}
+
+	fmt.Printf("Comparing %s...%s in %s/%s\n", base, head, owner, repoName)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), fetchStageTimeout)
+	defer cancel()
+
+	comparison, err := h.githubClient.CompareRefs(ctx, owner, repoName, base, head)
+	if err != nil {
+		var apiErr *github.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "repository or ref not found"})
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The tree delta is a nice-to-have; the commit and file lists stand on their own
+	baseTree, err := h.githubClient.FetchTreeAt(ctx, owner, repoName, base)
+	if err != nil {
+		fmt.Printf("Skipping tree delta for %s/%s: %v\n", owner, repoName, err)
+	} else if headTree, err := h.githubClient.FetchTreeAt(ctx, owner, repoName, head); err != nil {
+		fmt.Printf("Skipping tree delta for %s/%s: %v\n", owner, repoName, err)
+	} else {
+		comparison.Tree = analysis.CompareTrees(baseTree.BlobSizes(), headTree.BlobSizes())
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
 // SmartSummary generates an AI-powered summary of the repository
 func (h *Handler) SmartSummary(c *gin.Context) {
 	var req SmartSummaryRequest
@@ -97,24 +598,73 @@ func (h *Handler) SmartSummary(c *gin.Context) {
 		return
 	}
 
+	fullName := req.Owner + "/" + req.Repo
+
+	// The head commit names the tree; a stored summary of that tree is reused as long as the
+	// model and prompts haven't changed, which saves both Gemini calls and the file downloads
+	resolveCtx, cancelResolve := context.WithTimeout(c.Request.Context(), fetchStageTimeout)
+	head, err := h.githubClient.ResolveRef(resolveCtx, req.Owner, req.Repo, "HEAD")
+	cancelResolve()
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{
+			"error": err.Error(),
+			"stage": "scanning_structure",
+		})
+		return
+	}
+
+	if !req.Refresh && c.Query("refresh") != "true" {
+		summary, err := h.repo.GetSmartSummary(fullName, head.TreeSHA, req.IncludeSubmodules, ai.SmartSummaryModel, ai.SmartSummaryPromptVersion)
+		if err == nil {
+			fmt.Println("Returning stored smart summary for", fullName)
+			summary.Cached = true
+			c.JSON(http.StatusOK, summary)
+			return
+		}
+	}
+
 	fmt.Printf("Generating smart summary for %s/%s...\n", req.Owner, req.Repo)
 
-	summary, stage, err := h.geminiClient.GenerateSmartSummary(h.githubClient, req.Owner, req.Repo)
+	summary, stage, err := h.geminiClient.GenerateSmartSummary(c.Request.Context(), h.githubClient, req.Owner, req.Repo, head.SHA, req.IncludeSubmodules)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(statusForError(err), gin.H{
 			"error": err.Error(),
 			"stage": stage,
 		})
 		return
 	}
 
+	// A summary missing files because of a rate limit or timeout would be served for this
+	// tree forever; it is returned but not stored, so the next request tries again
+	if cacheableSummary(summary) {
+		if err := h.repo.SaveSmartSummary(fullName, req.IncludeSubmodules, summary); err != nil {
+			fmt.Println("Error saving to DB:", err)
+		}
+	} else {
+		fmt.Printf("Not storing smart summary for %s: some files were skipped for transient reasons\n", fullName)
+	}
+
 	c.JSON(http.StatusOK, summary)
 }
 
+// permanentSkipReasons are fetch failures that would happen again for the same tree
+var permanentSkipReasons = map[string]bool{"not_found": true, "too_large": true}
+
+// cacheableSummary reports whether every file a summary skipped was skipped for good
+func cacheableSummary(summary *models.SmartSummary) bool {
+	for _, skipped := range summary.SkippedFiles {
+		if !permanentSkipReasons[skipped.Reason] {
+			return false
+		}
+	}
+	return true
+}
+
 // FileTreeRequest represents the request for fetching file tree
 type FileTreeRequest struct {
-	Owner string `json:"owner" binding:"required"`
-	Repo  string `json:"repo" binding:"required"`
+	Owner             string `json:"owner" binding:"required"`
+	Repo              string `json:"repo" binding:"required"`
+	IncludeSubmodules bool   `json:"include_submodules"` // List the files inside GitHub-hosted submodules too
 }
 
 // GetFileTree fetches the file tree structure of a repository
@@ -127,7 +677,10 @@ func (h *Handler) GetFileTree(c *gin.Context) {
 
 	fmt.Printf("Fetching file tree for %s/%s...\n", req.Owner, req.Repo)
 
-	fileTree, err := h.githubClient.FetchFileTree(req.Owner, req.Repo)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), fetchStageTimeout)
+	defer cancel()
+
+	fileTree, err := h.githubClient.FetchFileTree(ctx, req.Owner, req.Repo, req.IncludeSubmodules)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -177,9 +730,12 @@ func (h *Handler) ChatWithRepo(c *gin.Context) {
 		History: req.History,
 	}
 
-	response, err := h.geminiClient.Chat(h.githubClient, chatReq)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), chatStageTimeout)
+	defer cancel()
+
+	response, err := h.geminiClient.Chat(ctx, h.githubClient, chatReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -224,19 +780,24 @@ func (h *Handler) VoiceChatWithRepo(c *gin.Context) {
 		History: req.History,
 	}
 
-	response, err := h.geminiClient.GenerateVoiceResponse(h.githubClient, chatReq)
+	chatCtx, cancelChat := context.WithTimeout(c.Request.Context(), chatStageTimeout)
+	response, err := h.geminiClient.GenerateVoiceResponse(chatCtx, h.githubClient, chatReq)
+	cancelChat()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Convert to speech
-	audioData, err := h.elevenLabsClient.TextToSpeech(response.Response)
+	speechCtx, cancelSpeech := context.WithTimeout(c.Request.Context(), speechStageTimeout)
+	defer cancelSpeech()
+	audioData, err := h.elevenLabsClient.TextToSpeech(speechCtx, response.Response)
 	if err != nil {
 		// Return text response even if TTS fails
 		fmt.Printf("TTS failed: %v, returning text only\n", err)
 		c.JSON(http.StatusOK, gin.H{
 			"response":    response.Response,
+			"skipped":     response.Skipped,
 			"audio":       nil,
 			"audio_error": err.Error(),
 		})
@@ -248,6 +809,7 @@ func (h *Handler) VoiceChatWithRepo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"response": response.Response,
+		"skipped":  response.Skipped,
 		"audio":    audioBase64,
 	})
 }
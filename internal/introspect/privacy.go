@@ -0,0 +1,101 @@
+package introspect
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// retentionPurgeInterval is how often expired reports are looked for
+const retentionPurgeInterval = time.Hour
+
+// EraseContributorRequest names one contributor by every identity they may be stored under
+type EraseContributorRequest struct {
+	Identities []string `json:"identities" binding:"required"` // GitHub logins, git author names and emails
+	Reason     string   `json:"reason"`                        // e.g. the privacy request's reference, kept in the log
+}
+
+// RequireAdminToken lets a request through only with "Authorization: Bearer <token>"
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// EraseContributor removes a contributor from every stored report, the commits table and
+// chat transcripts, and logs the erasure. Later analyses leave the identities out as well.
+func (h *Handler) EraseContributor(c *gin.Context) {
+	var req EraseContributorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Identities) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Required: identities"})
+		return
+	}
+
+	record, err := h.repo.EraseContributor(req.Identities, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The identities themselves are never logged
+	fmt.Printf("Erasure %d: contributor removed from %d reports, %d commits and %d chat sessions\n", record.ID, record.Reports, record.Commits, record.ChatSessions)
+	c.JSON(http.StatusOK, record)
+}
+
+// ListErasures returns the erasure log, newest first
+func (h *Handler) ListErasures(c *gin.Context) {
+	records, err := h.repo.ListErasures()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.ErasureRecord{}
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// StartRetentionPurge deletes reports and snapshots older than retention now and then every
+// hour, along with the stored commits of repositories left without a report
+func StartRetentionPurge(repo *ReportRepository, retention time.Duration) {
+	purge := func() {
+		reports, commits, err := repo.PurgeReports(time.Now().Add(-retention))
+		if err != nil {
+			fmt.Println("Retention purge failed:", err)
+			return
+		}
+		if reports > 0 || commits > 0 {
+			fmt.Printf("Retention purge: deleted %d reports older than %s and %d commits of repositories without a report\n", reports, retention, commits)
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(retentionPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purge()
+		}
+	}()
+}
+
+// erasedIdentities returns the hashes of erased identities. An analysis can't be saved
+// without them, or a database hiccup would publish erased contributors again.
+func (h *Handler) erasedIdentities() (map[string]bool, error) {
+	erased, err := h.repo.ErasedIdentities()
+	if err != nil {
+		return nil, fmt.Errorf("could not check erased contributors: %w", err)
+	}
+	return erased, nil
+}
@@ -1,10 +1,16 @@
 package introspect
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/analysis"
 	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReportRepository struct {
@@ -17,8 +23,10 @@ func NewReportRepository(db *gorm.DB) *ReportRepository {
 	}
 }
 
+// SaveReport stores an analysis as a new row; earlier analyses stay as the repo's history
 func (repo *ReportRepository) SaveReport(report *models.AnalyticsReport) error {
-	if err := repo.databaseConnection.Save(report).Error; err != nil {
+	report.ID = 0
+	if err := repo.databaseConnection.Create(report).Error; err != nil {
 		return fmt.Errorf("could not save report to the database: %w", err)
 	}
 	return nil
@@ -30,7 +38,8 @@ func (repo *ReportRepository) GetReportByRepoName(fullName string) (*models.Anal
 	// Note: GORM usually maps embedded struct fields with snake_case.
 	// If "full_name" doesn't work, we might need "repo_info_full_name".
 	// For now, let's assume the flatten worked or try standard match.
-	result := repo.databaseConnection.Where("full_name = ?", fullName).First(&report)
+	// Snapshots of past revisions share the table; the live report is the newest analysis of the head
+	result := repo.databaseConnection.Where("full_name = ? AND snapshot = ?", fullName, false).Order("generated_at DESC").First(&report)
 
 	if result.Error != nil {
 		return nil, fmt.Errorf("report not found: %w", result.Error)
@@ -38,3 +47,399 @@ func (repo *ReportRepository) GetReportByRepoName(fullName string) (*models.Anal
 
 	return &report, nil
 }
+
+// GetSnapshot returns the stored report of a repository at a past commit
+func (repo *ReportRepository) GetSnapshot(fullName, commitSHA string) (*models.AnalyticsReport, error) {
+	var report models.AnalyticsReport
+
+	result := repo.databaseConnection.Where("full_name = ? AND snapshot = ? AND commit_sha = ?", fullName, true, commitSHA).First(&report)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("snapshot not found: %w", result.Error)
+	}
+
+	return &report, nil
+}
+
+// GetCachedReport returns the newest stored report that covers the requested options: the
+// live report, or the snapshot at snapshotSHA when it is set. A report built with history
+// scanning also answers requests without it; submodules change the tree, so they must match.
+func (repo *ReportRepository) GetCachedReport(fullName, snapshotSHA string, scanHistory, includeSubmodules bool) (*models.AnalyticsReport, error) {
+	var report models.AnalyticsReport
+
+	query := repo.databaseConnection.Where("full_name = ? AND include_submodules = ?", fullName, includeSubmodules)
+	if snapshotSHA != "" {
+		query = query.Where("snapshot = ? AND commit_sha = ?", true, snapshotSHA)
+	} else {
+		query = query.Where("snapshot = ?", false)
+	}
+	if scanHistory {
+		query = query.Where("scan_history = ?", true)
+	}
+	result := query.Order("generated_at DESC").First(&report)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("report not found: %w", result.Error)
+	}
+
+	return &report, nil
+}
+
+// historyColumns are the columns a history listing needs, so it doesn't load every report section
+var historyColumns = []string{
+	"id", "generated_at", "commit_sha", "snapshot", "snapshot_ref", "snapshot_date",
+	"stars", "forks", "open_issues", "health",
+}
+
+// ListReports returns every stored analysis of a repository, newest first, with only the
+// columns of a history entry filled in
+func (repo *ReportRepository) ListReports(fullName string) ([]models.AnalyticsReport, error) {
+	var reports []models.AnalyticsReport
+
+	result := repo.databaseConnection.Select(historyColumns).Where("full_name = ?", fullName).Order("generated_at DESC, id DESC").Find(&reports)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not list reports: %w", result.Error)
+	}
+
+	return reports, nil
+}
+
+// GetReportByID returns one stored analysis of a repository
+func (repo *ReportRepository) GetReportByID(fullName string, id uint) (*models.AnalyticsReport, error) {
+	var report models.AnalyticsReport
+
+	result := repo.databaseConnection.Where("full_name = ? AND id = ?", fullName, id).First(&report)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("report not found: %w", result.Error)
+	}
+
+	return &report, nil
+}
+
+// commitBatchSize keeps each insert well under the bind parameter limits of SQLite and Postgres
+const commitBatchSize = 500
+
+// refreshedCommitColumns are what a commit seen again updates. The message can't change
+// under the same SHA, so it is never rewritten - which also keeps an erasure's redactions.
+var refreshedCommitColumns = []string{
+	"author_login", "author_name", "author_email", "author_avatar_url",
+	"additions", "deletions", "stats_known", "signature", "verified", "verification_reason",
+}
+
+// SaveCommits upserts commits into the commits table; a commit seen again is refreshed in place
+func (repo *ReportRepository) SaveCommits(commits []models.Commit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	result := repo.databaseConnection.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo"}, {Name: "sha"}},
+		DoUpdates: clause.AssignmentColumns(refreshedCommitColumns),
+	}).CreateInBatches(commits, commitBatchSize)
+
+	if result.Error != nil {
+		return fmt.Errorf("could not save commits to the database: %w", result.Error)
+	}
+	return nil
+}
+
+// GetCommits loads the stored rows of the given commits of a repository, in the order of shas
+func (repo *ReportRepository) GetCommits(fullName string, shas []string) ([]models.Commit, error) {
+	bySHA := make(map[string]models.Commit, len(shas))
+	for start := 0; start < len(shas); start += commitBatchSize {
+		end := min(start+commitBatchSize, len(shas))
+
+		var batch []models.Commit
+		result := repo.databaseConnection.Where("repo = ? AND sha IN ?", fullName, shas[start:end]).Find(&batch)
+
+		if result.Error != nil {
+			return nil, fmt.Errorf("could not load commits: %w", result.Error)
+		}
+		for _, commit := range batch {
+			bySHA[commit.SHA] = commit
+		}
+	}
+
+	commits := make([]models.Commit, 0, len(shas))
+	for _, sha := range shas {
+		if commit, ok := bySHA[sha]; ok {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// commitAuthor groups commits by GitHub login, or by git author name when there is none
+const commitAuthor = "COALESCE(NULLIF(author_login, ''), author_name)"
+
+// ContributorActivity totals the stored commits of a repository per author, most commits
+// first, optionally within an authored-at range
+func (repo *ReportRepository) ContributorActivity(fullName string, since, until *time.Time) ([]models.ContributorActivity, error) {
+	var activity []models.ContributorActivity
+
+	query := repo.databaseConnection.Model(&models.Commit{}).
+		Select(commitAuthor+" AS login, MAX(author_avatar_url) AS avatar_url, COUNT(*) AS commits, COALESCE(SUM(additions), 0) AS additions, COALESCE(SUM(deletions), 0) AS deletions").
+		Where("repo = ?", fullName)
+	if since != nil {
+		query = query.Where("authored_at >= ?", *since)
+	}
+	if until != nil {
+		query = query.Where("authored_at <= ?", *until)
+	}
+	result := query.Group(commitAuthor).Order("commits DESC, login").Scan(&activity)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not total commits: %w", result.Error)
+	}
+
+	return activity, nil
+}
+
+// SaveSmartSummary stores a generated Smart Summary
+func (repo *ReportRepository) SaveSmartSummary(fullName string, includeSubmodules bool, summary *models.SmartSummary) error {
+	stored := models.StoredSummary{
+		Repo:              fullName,
+		CommitSHA:         summary.CommitSHA,
+		TreeSHA:           summary.TreeSHA,
+		IncludeSubmodules: includeSubmodules,
+		Model:             summary.Model,
+		PromptVersion:     summary.PromptVersion,
+		Summary:           *summary,
+		GeneratedAt:       summary.GeneratedAt,
+	}
+	if err := repo.databaseConnection.Create(&stored).Error; err != nil {
+		return fmt.Errorf("could not save smart summary to the database: %w", err)
+	}
+	return nil
+}
+
+// GetSmartSummary returns the newest stored Smart Summary of a tree written by the given
+// model and prompt version
+func (repo *ReportRepository) GetSmartSummary(fullName, treeSHA string, includeSubmodules bool, model, promptVersion string) (*models.SmartSummary, error) {
+	var stored models.StoredSummary
+
+	result := repo.databaseConnection.
+		Where("repo = ? AND tree_sha = ? AND include_submodules = ? AND model = ? AND prompt_version = ?", fullName, treeSHA, includeSubmodules, model, promptVersion).
+		Order("generated_at DESC").
+		First(&stored)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("smart summary not found: %w", result.Error)
+	}
+
+	return &stored.Summary, nil
+}
+
+// ErrSessionChanged means another message was added to the session since it was loaded
+var ErrSessionChanged = errors.New("chat session was changed by another request")
+
+// CreateChatSession stores a new, empty chat session
+func (repo *ReportRepository) CreateChatSession(session *models.ChatSession) error {
+	if err := repo.databaseConnection.Create(session).Error; err != nil {
+		return fmt.Errorf("could not save chat session to the database: %w", err)
+	}
+	return nil
+}
+
+// GetChatSession returns a chat session with its full transcript
+func (repo *ReportRepository) GetChatSession(id string) (*models.ChatSession, error) {
+	var session models.ChatSession
+
+	result := repo.databaseConnection.Where("id = ?", id).First(&session)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("chat session not found: %w", result.Error)
+	}
+
+	return &session, nil
+}
+
+// ListChatSessions returns the chat sessions of a repository, most recently active first
+func (repo *ReportRepository) ListChatSessions(fullName string) ([]models.ChatSession, error) {
+	var sessions []models.ChatSession
+
+	result := repo.databaseConnection.Where("repo = ?", fullName).Order("updated_at DESC").Find(&sessions)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not list chat sessions: %w", result.Error)
+	}
+
+	return sessions, nil
+}
+
+// AppendChatTurns adds turns to a session loaded earlier. It fails with ErrSessionChanged when
+// the session was updated in between, instead of overwriting the other request's turns.
+func (repo *ReportRepository) AppendChatTurns(session *models.ChatSession, turns ...models.ChatTurn) error {
+	loadedVersion := session.Version
+	session.Messages = append(session.Messages, turns...)
+	session.Version++
+
+	result := repo.databaseConnection.Model(session).
+		Where("version = ?", loadedVersion).
+		Select("messages", "version", "updated_at").
+		Updates(session)
+
+	if result.Error != nil {
+		return fmt.Errorf("could not save chat message: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionChanged
+	}
+	return nil
+}
+
+// PurgeReports deletes the analyses and snapshots generated before the cutoff, then the
+// stored commits of repositories that no longer have any report
+func (repo *ReportRepository) PurgeReports(before time.Time) (reports int64, commits int64, err error) {
+	result := repo.databaseConnection.Where("generated_at < ?", before).Delete(&models.AnalyticsReport{})
+	if result.Error != nil {
+		return 0, 0, fmt.Errorf("could not purge reports: %w", result.Error)
+	}
+	reports = result.RowsAffected
+
+	orphans := repo.databaseConnection.Where("repo NOT IN (?)", repo.databaseConnection.Model(&models.AnalyticsReport{}).Distinct("full_name")).Delete(&models.Commit{})
+	if orphans.Error != nil {
+		return reports, 0, fmt.Errorf("could not purge commits: %w", orphans.Error)
+	}
+	return reports, orphans.RowsAffected, nil
+}
+
+// reportBatchSize is how many stored reports an erasure loads at a time
+const reportBatchSize = 50
+
+// EraseContributor removes a contributor, known by the given logins, names and emails, from
+// every stored report, the commits table and chat transcripts, and logs the erasure. It runs
+// in one transaction, so a failure leaves nothing half-erased.
+func (repo *ReportRepository) EraseContributor(identities []string, reason string) (*models.ErasureRecord, error) {
+	erased := make(map[string]bool)
+	var lowered []string
+	for _, identity := range identities {
+		if hash := analysis.IdentityHash(identity); hash != "" && !erased[hash] {
+			erased[hash] = true
+			lowered = append(lowered, strings.ToLower(strings.TrimSpace(identity)))
+		}
+	}
+	if len(erased) == 0 {
+		return nil, errors.New("no identity to erase")
+	}
+
+	record := &models.ErasureRecord{Reason: reason}
+	err := repo.databaseConnection.Transaction(func(tx *gorm.DB) error {
+		// Commits they authored lose their author; commits whose message mentions them
+		// (e.g. a Co-authored-by trailer) get the mention redacted
+		var commits []models.Commit
+		query := tx.Where("LOWER(author_login) IN ? OR LOWER(author_name) IN ? OR LOWER(author_email) IN ?", lowered, lowered, lowered)
+		for _, identity := range lowered {
+			query = query.Or("LOWER(message) LIKE ?", "%"+identity+"%")
+		}
+		if err := query.Find(&commits).Error; err != nil {
+			return fmt.Errorf("could not load commits: %w", err)
+		}
+		for i := range commits {
+			commit := &commits[i]
+			authored := analysis.EraseCommitAuthor(commit, erased)
+			message := analysis.RedactIdentities(commit.Message, identities)
+			if !authored && message == commit.Message {
+				continue
+			}
+			commit.Message = message
+			if err := tx.Save(commit).Error; err != nil {
+				return fmt.Errorf("could not anonymize commit %s: %w", commit.SHA, err)
+			}
+			record.Commits++
+		}
+
+		var reports []models.AnalyticsReport
+		result := tx.FindInBatches(&reports, reportBatchSize, func(_ *gorm.DB, _ int) error {
+			for i := range reports {
+				if analysis.EraseContributor(&reports[i], erased) == 0 {
+					continue
+				}
+				if err := tx.Save(&reports[i]).Error; err != nil {
+					return fmt.Errorf("could not update report %d: %w", reports[i].ID, err)
+				}
+				record.Reports++
+			}
+			return nil
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var sessions []models.ChatSession
+		result = tx.FindInBatches(&sessions, reportBatchSize, func(_ *gorm.DB, _ int) error {
+			for i := range sessions {
+				session := &sessions[i]
+				changed := false
+				for j := range session.Messages {
+					redacted := analysis.RedactIdentities(session.Messages[j].Content, identities)
+					if redacted != session.Messages[j].Content {
+						session.Messages[j].Content = redacted
+						changed = true
+					}
+				}
+				if !changed {
+					continue
+				}
+				// Bumping the version makes a message posted meanwhile retry on the redacted transcript
+				session.Version++
+				if err := tx.Model(session).Select("messages", "version").Updates(session).Error; err != nil {
+					return fmt.Errorf("could not update chat session %s: %w", session.ID, err)
+				}
+				record.ChatSessions++
+			}
+			return nil
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for hash := range erased {
+			record.IdentityHashes = append(record.IdentityHashes, hash)
+		}
+		sort.Strings(record.IdentityHashes)
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("could not log the erasure: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ErasedIdentities returns the hashes of every identity erased so far, so new analyses
+// can leave them out
+func (repo *ReportRepository) ErasedIdentities() (map[string]bool, error) {
+	var records []models.ErasureRecord
+
+	result := repo.databaseConnection.Select("identity_hashes").Find(&records)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not load erased identities: %w", result.Error)
+	}
+
+	erased := make(map[string]bool)
+	for _, record := range records {
+		for _, hash := range record.IdentityHashes {
+			erased[hash] = true
+		}
+	}
+	return erased, nil
+}
+
+// ListErasures returns the erasure log, newest first
+func (repo *ReportRepository) ListErasures() ([]models.ErasureRecord, error) {
+	var records []models.ErasureRecord
+
+	result := repo.databaseConnection.Order("created_at DESC, id DESC").Find(&records)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not list erasures: %w", result.Error)
+	}
+
+	return records, nil
+}
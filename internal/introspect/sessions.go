@@ -0,0 +1,229 @@
+package introspect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/ai"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/github"
+	"github.com/prajithravisankar/mlh_hack_for_hackers_hacker_introspector/internal/models"
+)
+
+// maxSessionMessages caps a transcript; the whole history is sent to Gemini on every turn
+const maxSessionMessages = 200
+
+// CreateChatSessionRequest starts a conversation about up to 10 files
+type CreateChatSessionRequest struct {
+	Owner string   `json:"owner" binding:"required"`
+	Repo  string   `json:"repo" binding:"required"`
+	Files []string `json:"files" binding:"required"`
+	Ref   string   `json:"ref"` // Branch, tag or SHA to read the files at; the default branch when empty
+}
+
+// ChatSessionMessageRequest is one user turn in a session
+type ChatSessionMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+	Voice   bool   `json:"voice"` // Answer in voice mode, with audio
+}
+
+// CreateChatSession starts a stored chat session. The ref is resolved to a commit SHA now,
+// so every turn (and anyone the session is shared with) reads the same version of the files.
+func (h *Handler) CreateChatSession(c *gin.Context) {
+	var req CreateChatSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Required: owner, repo, files"})
+		return
+	}
+
+	if len(req.Files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one file must be selected"})
+		return
+	}
+
+	if len(req.Files) > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Maximum 10 files allowed"})
+		return
+	}
+
+	ref := req.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	resolveCtx, cancel := context.WithTimeout(c.Request.Context(), fetchStageTimeout)
+	defer cancel()
+	commit, err := h.githubClient.ResolveRef(resolveCtx, req.Owner, req.Repo, ref)
+	if err != nil {
+		var apiErr *github.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusUnprocessableEntity) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := &models.ChatSession{
+		ID:       id,
+		Repo:     req.Owner + "/" + req.Repo,
+		Files:    req.Files,
+		Ref:      commit.SHA,
+		Messages: []models.ChatTurn{},
+	}
+	if err := h.repo.CreateChatSession(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListChatSessions lists the stored chat sessions of a repository, most recently active first.
+// ?owner= and ?repo= are required. A session ID is all it takes to read and continue a
+// conversation, so this is only routed behind the admin token, and lists no transcripts.
+func (h *Handler) ListChatSessions(c *gin.Context) {
+	owner, repoName := c.Query("owner"), c.Query("repo")
+	if owner == "" || repoName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner and repo are required"})
+		return
+	}
+
+	sessions, err := h.repo.ListChatSessions(owner + "/" + repoName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summaries := make([]models.ChatSessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		summaries = append(summaries, models.ChatSessionSummary{
+			ID:           session.ID,
+			Repo:         session.Repo,
+			Files:        session.Files,
+			Ref:          session.Ref,
+			MessageCount: len(session.Messages),
+			CreatedAt:    session.CreatedAt,
+			UpdatedAt:    session.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetChatSession returns a chat session with its full transcript
+func (h *Handler) GetChatSession(c *gin.Context) {
+	session, err := h.repo.GetChatSession(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chat session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// PostChatMessage answers a message in a session, using the stored transcript as history,
+// and appends both turns. With "voice": true the answer is short and comes with audio.
+func (h *Handler) PostChatMessage(c *gin.Context) {
+	var req ChatSessionMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Required: message"})
+		return
+	}
+
+	session, err := h.repo.GetChatSession(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chat session not found"})
+		return
+	}
+	if len(session.Messages)+2 > maxSessionMessages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This conversation is full. Start a new session to keep going"})
+		return
+	}
+
+	owner, repoName, _ := strings.Cut(session.Repo, "/")
+	chatReq := &ai.ChatRequest{
+		Owner:   owner,
+		Repo:    repoName,
+		Files:   session.Files,
+		Ref:     session.Ref,
+		Message: req.Message,
+	}
+	for _, turn := range session.Messages {
+		chatReq.History = append(chatReq.History, ai.ChatMessage{Role: turn.Role, Content: turn.Content})
+	}
+
+	fmt.Printf("Chat session %s for %s: message %d\n", session.ID, session.Repo, len(session.Messages)/2+1)
+
+	asked := time.Now()
+	chatCtx, cancelChat := context.WithTimeout(c.Request.Context(), chatStageTimeout)
+	var response *ai.ChatResponse
+	if req.Voice {
+		response, err = h.geminiClient.GenerateVoiceResponse(chatCtx, h.githubClient, chatReq)
+	} else {
+		response, err = h.geminiClient.Chat(chatCtx, h.githubClient, chatReq)
+	}
+	cancelChat()
+	if err != nil {
+		c.JSON(statusForError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.repo.AppendChatTurns(session,
+		models.ChatTurn{Role: "user", Content: req.Message, Voice: req.Voice, At: asked},
+		models.ChatTurn{Role: "assistant", Content: response.Response, Voice: req.Voice, At: time.Now()},
+	)
+	if errors.Is(err, ErrSessionChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another message was sent in this session at the same time. Reload it and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := gin.H{
+		"session_id":    session.ID,
+		"response":      response.Response,
+		"skipped":       response.Skipped,
+		"message_count": len(session.Messages),
+	}
+	if !req.Voice {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// Convert to speech; the transcript keeps only the text
+	speechCtx, cancelSpeech := context.WithTimeout(c.Request.Context(), speechStageTimeout)
+	defer cancelSpeech()
+	audioData, err := h.elevenLabsClient.TextToSpeech(speechCtx, response.Response)
+	if err != nil {
+		fmt.Printf("TTS failed: %v, returning text only\n", err)
+		result["audio"] = nil
+		result["audio_error"] = err.Error()
+	} else {
+		result["audio"] = base64.StdEncoding.EncodeToString(audioData)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// newSessionID returns a random, unguessable session ID; knowing it is what lets someone read a session
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("could not generate a session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -4,16 +4,26 @@ import "time"
 
 // Repository represents the metadata.
 type Repository struct {
-	Name        string         `json:"name"`
-	FullName    string         `json:"full_name" gorm:"index"`
-	Description string         `json:"description"`
-	HTMLURL     string         `json:"html_url"`
-	Language    string         `json:"language"`
-	Languages   map[string]int `json:"languages" gorm:"serializer:json"`
-	Stars       int            `json:"stargazers_count"`
-	Forks       int            `json:"forks_count"`
-	OpenIssues  int            `json:"open_issues_count"`
-	CreatedAt   time.Time      `json:"created_at"`
+	Name          string         `json:"name"`
+	FullName      string         `json:"full_name" gorm:"index"`
+	Description   string         `json:"description"`
+	HTMLURL       string         `json:"html_url"`
+	DefaultBranch string         `json:"default_branch"`
+	Language      string         `json:"language"`
+	Languages     map[string]int `json:"languages" gorm:"serializer:json"`
+	Stars         int            `json:"stargazers_count"`
+	Forks         int            `json:"forks_count"`
+	OpenIssues    int            `json:"open_issues_count"`
+	License       *RepoLicense   `json:"license" gorm:"serializer:json"`
+	Topics        []string       `json:"topics" gorm:"serializer:json"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// RepoLicense is GitHub's own license guess for a repository (null when it found none).
+type RepoLicense struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	SPDXID string `json:"spdx_id"`
 }
 
 // ContributorStats models the "Who Did What" data.
@@ -31,27 +41,603 @@ type ContributorStats struct {
 	} `json:"weeks"`
 }
 
+// CommitActivity is who wrote a commit and when, in the author's own timezone
+type CommitActivity struct {
+	SHA         string    `json:"sha"`
+	Author      string    `json:"author"`
+	Date        time.Time `json:"date"`
+	UTCOffset   int       `json:"utc_offset"`   // Minutes east of UTC
+	OffsetKnown bool      `json:"offset_known"` // false when only the UTC time was available
+
+	Signature          string `json:"signature,omitempty"`           // "gpg", "ssh", "x509", or "" when unsigned
+	Verified           bool   `json:"verified"`                      // GitHub checked the signature against the author's keys
+	VerificationReason string `json:"verification_reason,omitempty"` // GitHub's reason, e.g. "valid", "unsigned", "unknown_key"
+}
+
+// Commit is one row of the commits table: a commit of an analyzed repository with its author
+// identity, both timestamps (stored in UTC, with the original offsets), message and line stats
+type Commit struct {
+	ID              uint      `json:"-" gorm:"primaryKey"`
+	Repo            string    `json:"repo" gorm:"uniqueIndex:idx_commits_repo_sha"` // owner/name
+	SHA             string    `json:"sha" gorm:"uniqueIndex:idx_commits_repo_sha"`
+	AuthorLogin     string    `json:"author_login"` // "" when the email isn't linked to a GitHub account
+	AuthorName      string    `json:"author_name"`
+	AuthorEmail     string    `json:"author_email"`
+	AuthorAvatarURL string    `json:"author_avatar_url"`
+	AuthoredAt      time.Time `json:"authored_at"`
+	AuthoredOffset  int       `json:"authored_offset"` // Minutes east of UTC
+	CommittedAt     time.Time `json:"committed_at"`
+	CommittedOffset int       `json:"committed_offset"`
+	OffsetKnown     bool      `json:"offset_known"` // false when only the UTC times were available
+	Message         string    `json:"message"`
+	Additions       int       `json:"additions"`
+	Deletions       int       `json:"deletions"`
+	StatsKnown      bool      `json:"stats_known"` // false when the line stats couldn't be fetched
+
+	Signature          string `json:"signature,omitempty"`
+	Verified           bool   `json:"verified"`
+	VerificationReason string `json:"verification_reason,omitempty"`
+}
+
+// ContributorActivity is one author's totals over the commits table
+type ContributorActivity struct {
+	Login     string `json:"login"` // GitHub login, or the git author name
+	AvatarURL string `json:"avatar_url,omitempty"`
+	Commits   int    `json:"commits"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
 // AnalyticsReport is the "Master Table" in our database.
 type AnalyticsReport struct {
-	ID             uint               `json:"id" gorm:"primaryKey"`
-	RepoInfo       Repository         `json:"repo_info" gorm:"embedded"`
-	Contributors   []ContributorStats `json:"contributors" gorm:"serializer:json"`
-	FileTypes      map[string]int     `json:"file_types" gorm:"serializer:json"`
-	CommitTimeline []time.Time        `json:"commit_timeline" gorm:"serializer:json"` // <--- NEW FIELD
-	GeneratedAt    time.Time          `json:"generated_at"`
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	RepoInfo        Repository          `json:"repo_info" gorm:"embedded"`
+	Contributors    []ContributorStats  `json:"contributors" gorm:"serializer:json"`
+	FileTypes       map[string]int      `json:"file_types" gorm:"serializer:json"`
+	CommitTimeline  []time.Time         `json:"commit_timeline" gorm:"serializer:json"` // <--- NEW FIELD
+	Commits         []CommitActivity    `json:"commits" gorm:"serializer:json"`
+	WorkingHours    WorkingHoursReport  `json:"working_hours" gorm:"serializer:json"`
+	Dependencies    DependencyInventory `json:"dependencies" gorm:"serializer:json"`
+	Licenses        LicenseReport       `json:"licenses" gorm:"serializer:json"`
+	Secrets         SecretScanReport    `json:"secrets" gorm:"serializer:json"`
+	Vulnerabilities VulnerabilityReport `json:"vulnerabilities" gorm:"serializer:json"`
+	CI              CIReport            `json:"ci" gorm:"serializer:json"`
+	Testing         TestReport          `json:"testing" gorm:"serializer:json"`
+	Workspace       WorkspaceReport     `json:"workspace" gorm:"serializer:json"`
+	Submodules      []Submodule         `json:"submodules" gorm:"serializer:json"`
+	Signatures      SignatureReport     `json:"signatures" gorm:"serializer:json"`
+	Health          HealthReport        `json:"health" gorm:"serializer:json"`
+	CommitSHA       string              `json:"commit_sha"` // HEAD of the default branch when analyzed
+	GeneratedAt     time.Time           `json:"generated_at"`
+
+	// Snapshot reports are built from a past revision and stored next to the live report
+	Snapshot     bool       `json:"snapshot" gorm:"default:false;index"`
+	SnapshotRef  string     `json:"snapshot_ref,omitempty"`  // The ref or as-of date that was asked for
+	SnapshotDate *time.Time `json:"snapshot_date,omitempty"` // Committer date of CommitSHA
+
+	// The options the report was built with; a cached report only answers requests it covers
+	ScanHistory       bool `json:"scan_history" gorm:"default:false"`
+	IncludeSubmodules bool `json:"include_submodules" gorm:"default:false"`
+
+	Cache *CacheInfo `json:"cache,omitempty" gorm:"-"` // Set on /api/analyze responses, never stored
+}
+
+// CacheInfo tells the client how old a served report is and whether a newer one is coming
+type CacheInfo struct {
+	Status       string    `json:"status"` // "miss" (built for this request), "hit" or "stale" (past the TTL)
+	AgeSeconds   int       `json:"age_seconds"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	SourceSHA    string    `json:"source_sha"`   // Commit the report was built from
+	TTLSeconds   int       `json:"ttl_seconds"`  // 0 when cached reports never go stale
+	Revalidating bool      `json:"revalidating"` // A fresh report is being built in the background
+}
+
+// Dependency is a single package declared in a manifest or pinned in a lockfile.
+type Dependency struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`          // "go", "npm", "pypi", "cargo", "maven", "rubygems"
+	Version   string `json:"version"`            // Declared constraint, or the locked version for transitive deps
+	Resolved  string `json:"resolved,omitempty"` // Exact version from a lockfile, when one exists
+	Direct    bool   `json:"direct"`
+	Scope     string `json:"scope,omitempty"`   // "runtime", "dev", "test", "build", "optional"
+	Source    string `json:"source"`            // Path of the manifest/lockfile it came from
+	License   string `json:"license,omitempty"` // SPDX id or expression, when the lockfile records one
+}
+
+// DependencyInventory is the deterministic "what does this depend on" section of a report.
+type DependencyInventory struct {
+	Manifests       []string     `json:"manifests"`
+	Dependencies    []Dependency `json:"dependencies"`
+	DirectCount     int          `json:"direct_count"`
+	TransitiveCount int          `json:"transitive_count"`
 }
 
 // SmartSummary represents AI-generated insights about a repository
 type SmartSummary struct {
-	Archetype        string   `json:"archetype"`          // e.g., "REST API in Go"
-	OneLiner         string   `json:"one_liner"`          // "A high-performance analytics engine..."
-	KeyTech          []string `json:"key_tech"`           // ["Gin", "GORM", "Next.js"]
-	CodeQualityScore int      `json:"code_quality_score"` // 1-10
-	Complexity       string   `json:"complexity"`         // "Low", "Medium", "High"
-	LatexCode        string   `json:"latex_code"`         // LaTeX resume entry for hackathon
+	Archetype        string        `json:"archetype"`          // e.g., "REST API in Go"
+	OneLiner         string        `json:"one_liner"`          // "A high-performance analytics engine..."
+	KeyTech          []string      `json:"key_tech"`           // ["Gin", "GORM", "Next.js"]
+	CodeQualityScore int           `json:"code_quality_score"` // 1-10
+	Complexity       string        `json:"complexity"`         // "Low", "Medium", "High"
+	LatexCode        string        `json:"latex_code"`         // LaTeX resume entry for hackathon
+	SkippedFiles     []SkippedFile `json:"skipped_files"`      // Critical files that could not be fetched
+
+	CriticalFiles []string  `json:"critical_files"` // What Stage 1 picked for Stage 2 to read
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"prompt_version"`
+	CommitSHA     string    `json:"commit_sha"`
+	TreeSHA       string    `json:"tree_sha"` // The summary is reused until the tree changes
+	GeneratedAt   time.Time `json:"generated_at"`
+	Cached        bool      `json:"cached"` // Served from the database rather than generated for this request
+}
+
+// StoredSummary is a Smart Summary kept in the database, keyed by the tree it describes
+// and the model and prompt version that wrote it
+type StoredSummary struct {
+	ID                uint         `json:"id" gorm:"primaryKey"`
+	Repo              string       `json:"repo"` // owner/name
+	CommitSHA         string       `json:"commit_sha"`
+	TreeSHA           string       `json:"tree_sha"`
+	IncludeSubmodules bool         `json:"include_submodules"`
+	Model             string       `json:"model"`
+	PromptVersion     string       `json:"prompt_version"`
+	Summary           SmartSummary `json:"summary" gorm:"serializer:json"`
+	GeneratedAt       time.Time    `json:"generated_at"`
+}
+
+// SkippedFile is a file that was asked for but could not be read, and why
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"` // "not_found", "too_large", "rate_limited", "forbidden", "canceled" or "request_failed"
 }
 
 // FileTreeResponse represents the structure analysis from Stage 1
 type FileTreeResponse struct {
 	Files []string `json:"files"`
 }
+
+// LicenseReport is the license section of a report: what the project is licensed under,
+// where that came from, and which dependencies don't fit with it.
+type LicenseReport struct {
+	Project      string            `json:"project"`       // SPDX id or expression, "" when unlicensed
+	Source       string            `json:"source"`        // "license_file", "github" or "none"
+	LicenseFiles []LicenseFile     `json:"license_files"` // LICENSE/COPYING files and what they matched
+	FileHeaders  []LicenseHeader   `json:"file_headers"`  // SPDX-License-Identifier headers in source files
+	Conflicts    []LicenseConflict `json:"conflicts"`     // Dependencies whose license clashes with Project
+	FilesScanned int               `json:"files_scanned"` // Source files checked for headers
+}
+
+// LicenseFile is a license text found in the tree
+type LicenseFile struct {
+	Path       string  `json:"path"`
+	License    string  `json:"license"`    // "" when the text matched nothing in the corpus
+	Confidence float64 `json:"confidence"` // 0-1 share of the reference text found in the file
+}
+
+// LicenseHeader is a per-file SPDX-License-Identifier declaration
+type LicenseHeader struct {
+	Path    string `json:"path"`
+	License string `json:"license"`
+}
+
+// LicenseConflict flags a dependency whose license is incompatible with the project license
+type LicenseConflict struct {
+	Dependency string `json:"dependency"`
+	Ecosystem  string `json:"ecosystem"`
+	License    string `json:"license"`
+	Severity   string `json:"severity"` // "high" or "medium"
+	Reason     string `json:"reason"`
+}
+
+// SecretScanReport is the leaked-credentials section of a report
+type SecretScanReport struct {
+	Findings          []SecretFinding `json:"findings"`
+	FilesScanned      int             `json:"files_scanned"`
+	CommitsScanned    int             `json:"commits_scanned"` // 0 unless history scanning was requested
+	HighSeverityCount int             `json:"high_severity_count"`
+}
+
+// SecretFinding is one suspected credential. Match is always redacted before it is stored.
+type SecretFinding struct {
+	Path        string `json:"path"`
+	Line        int    `json:"line,omitempty"` // 0 for findings about the file itself (e.g. a committed .env)
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"` // "critical", "high" or "medium"
+	Match       string `json:"match,omitempty"`
+	Commit      string `json:"commit,omitempty"` // Set when the secret was found in commit history
+}
+
+// VulnerabilityReport is the known-vulnerabilities section of a report, matched offline
+// against an OSV snapshot. Database is empty when no snapshot was configured.
+type VulnerabilityReport struct {
+	Database          string          `json:"database"`
+	Findings          []Vulnerability `json:"findings"`
+	Scanned           int             `json:"scanned"`    // Dependencies with an exact version we could check
+	Unresolved        int             `json:"unresolved"` // Dependencies skipped because only a range was declared
+	HighSeverityCount int             `json:"high_severity_count"`
+}
+
+// Vulnerability is one advisory affecting one installed dependency version
+type Vulnerability struct {
+	Package          string   `json:"package"`
+	Ecosystem        string   `json:"ecosystem"`
+	InstalledVersion string   `json:"installed_version"`
+	AdvisoryID       string   `json:"advisory_id"`
+	Aliases          []string `json:"aliases,omitempty"`
+	Summary          string   `json:"summary"`
+	Severity         string   `json:"severity"`        // "CRITICAL", "HIGH", "MEDIUM", "LOW" or "UNKNOWN"
+	Score            float64  `json:"score,omitempty"` // CVSS base score when the advisory has a vector
+	FixedVersion     string   `json:"fixed_version,omitempty"`
+	Direct           bool     `json:"direct"`
+	Source           string   `json:"source"`
+}
+
+// CIReport is the "how is this built and shipped" section of a report
+type CIReport struct {
+	Providers       []string     `json:"providers"` // e.g. "github-actions", "gitlab-ci", "jenkins"
+	Pipelines       []CIPipeline `json:"pipelines"`
+	Secrets         []string     `json:"secrets"`          // Names of every secret referenced, never values
+	UnpinnedActions int          `json:"unpinned_actions"` // Third-party actions not pinned to a commit SHA
+}
+
+// CIPipeline is one CI configuration file
+type CIPipeline struct {
+	Path     string     `json:"path"`
+	Provider string     `json:"provider"`
+	Name     string     `json:"name,omitempty"`
+	Triggers []string   `json:"triggers"` // "push", "pull_request", "schedule", "workflow_dispatch"...
+	Jobs     []CIJob    `json:"jobs"`
+	Actions  []CIAction `json:"actions"`
+	Secrets  []string   `json:"secrets"`
+}
+
+// CIJob is one job (or stage, for Jenkins) of a pipeline
+type CIJob struct {
+	Name       string   `json:"name"`
+	Runners    []string `json:"runners"`               // Runner labels, pools or container images
+	MatrixSize int      `json:"matrix_size,omitempty"` // Jobs the matrix expands to; 0 without a (static) matrix
+	Steps      int      `json:"steps,omitempty"`
+}
+
+// CIAction is a reusable piece of someone else's pipeline: a GitHub action, orb, include, pipe...
+type CIAction struct {
+	Uses       string `json:"uses"`
+	Ref        string `json:"ref,omitempty"`
+	Pinning    string `json:"pinning"`     // "sha", "tag", "branch" or "unpinned"
+	FirstParty bool   `json:"first_party"` // Published by the CI provider itself
+}
+
+// TestReport is the testing section of a report: how much test code there is, where,
+// and what runs it. Line counts are estimated from blob sizes for files we didn't download.
+type TestReport struct {
+	SourceFiles      int             `json:"source_files"`
+	TestFiles        int             `json:"test_files"`
+	CodeLines        int             `json:"code_lines"`
+	TestLines        int             `json:"test_lines"`
+	Ratio            float64         `json:"ratio"` // Test lines per line of production code
+	LinesEstimated   bool            `json:"lines_estimated"`
+	Frameworks       []string        `json:"frameworks"`
+	Directories      []TestDirectory `json:"directories"`       // Largest directories first
+	UntestedPackages []string        `json:"untested_packages"` // Directories with code but no tests
+}
+
+// TestDirectory is the test-to-code breakdown for one directory
+type TestDirectory struct {
+	Path        string  `json:"path"`
+	SourceFiles int     `json:"source_files"`
+	TestFiles   int     `json:"test_files"`
+	CodeLines   int     `json:"code_lines"`
+	TestLines   int     `json:"test_lines"`
+	Ratio       float64 `json:"ratio"`
+}
+
+// WorkingHoursReport is the timezone section of a report: when each contributor usually
+// works, where they probably are, and when the team is online together (all in UTC hours)
+type WorkingHoursReport struct {
+	Contributors   []ContributorHours `json:"contributors"`
+	OverlapWindows []OverlapWindow    `json:"overlap_windows"`
+	OffsetsKnown   int                `json:"offsets_known"` // Commits whose author timezone was available
+}
+
+// ContributorHours is one contributor's working pattern, in their local time
+type ContributorHours struct {
+	Author           string  `json:"author"`
+	Commits          int     `json:"commits"`
+	Hours            [24]int `json:"hours"`    // Commits per local hour of day
+	Weekdays         [7]int  `json:"weekdays"` // Commits per local weekday, Sunday first
+	Timezone         string  `json:"timezone"` // Most common offset, e.g. "UTC+05:30"
+	UTCOffset        int     `json:"utc_offset"`
+	TimezoneInferred bool    `json:"timezone_inferred"` // Guessed from activity because no offsets were known
+	CoreStartUTC     int     `json:"core_start_utc"`    // The busiest 8-hour window, as UTC hours
+	CoreEndUTC       int     `json:"core_end_utc"`
+	AfterHoursRate   float64 `json:"after_hours_rate"` // Share of commits outside 09:00-18:00 local
+	WeekendRate      float64 `json:"weekend_rate"`
+}
+
+// OverlapWindow is a run of UTC hours in which the same contributors are all in their core hours
+type OverlapWindow struct {
+	StartUTC     int      `json:"start_utc"`
+	EndUTC       int      `json:"end_utc"` // Exclusive
+	Contributors []string `json:"contributors"`
+}
+
+// RefComparison is what changed between two revisions (tags, branches or SHAs) of a repository
+type RefComparison struct {
+	Repo             string                `json:"repo"`
+	Base             string                `json:"base"`
+	Head             string                `json:"head"`
+	BaseSHA          string                `json:"base_sha"`
+	MergeBaseSHA     string                `json:"merge_base_sha"`
+	Status           string                `json:"status"` // "ahead", "behind", "diverged" or "identical"
+	AheadBy          int                   `json:"ahead_by"`
+	BehindBy         int                   `json:"behind_by"`
+	TotalCommits     int                   `json:"total_commits"`
+	Commits          []ComparedCommit      `json:"commits"` // Oldest first
+	CommitsTruncated bool                  `json:"commits_truncated"`
+	Files            []ChangedFile         `json:"files"`
+	FilesTruncated   bool                  `json:"files_truncated"` // GitHub lists at most 300 changed files
+	Additions        int                   `json:"additions"`
+	Deletions        int                   `json:"deletions"`
+	Contributors     []ComparedContributor `json:"contributors"` // Most commits first
+	Tree             TreeDelta             `json:"tree"`
+}
+
+// ComparedCommit is one commit between the two refs
+type ComparedCommit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Message string    `json:"message"` // First line only
+	Date    time.Time `json:"date"`
+}
+
+// ChangedFile is one file added, removed, modified or renamed between the two refs
+type ChangedFile struct {
+	Path         string `json:"path"`
+	PreviousPath string `json:"previous_path,omitempty"` // Set for renames
+	Status       string `json:"status"`                  // "added", "removed", "modified", "renamed"...
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+}
+
+// ComparedContributor is someone who authored commits between the two refs
+type ComparedContributor struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	Commits   int    `json:"commits"`
+}
+
+// TreeDelta compares the file trees of two revisions
+type TreeDelta struct {
+	BaseFiles int             `json:"base_files"`
+	HeadFiles int             `json:"head_files"`
+	BaseBytes int             `json:"base_bytes"`
+	HeadBytes int             `json:"head_bytes"`
+	Languages []LanguageDelta `json:"languages"` // Biggest change first
+}
+
+// LanguageDelta is how much code of one language the tree gained or lost
+type LanguageDelta struct {
+	Language  string `json:"language"`
+	BaseBytes int    `json:"base_bytes"`
+	HeadBytes int    `json:"head_bytes"`
+	Delta     int    `json:"delta"`
+	BaseFiles int    `json:"base_files"`
+	HeadFiles int    `json:"head_files"`
+}
+
+// WorkspaceReport is the monorepo section of a report: the packages it holds, each with
+// its own slice of the analysis. Monorepo is false for single-project repositories.
+type WorkspaceReport struct {
+	Monorepo  bool            `json:"monorepo"`
+	Tools     []string        `json:"tools"` // "go-modules", "go-work", "npm-workspaces", "pnpm-workspaces", "lerna", "cargo-workspace", "bazel"
+	Packages  []PackageReport `json:"packages"`
+	Truncated bool            `json:"truncated"` // More packages than the report keeps
+}
+
+// PackageReport is one workspace package's slice of a report
+type PackageReport struct {
+	Path          string               `json:"path"` // Directory, "." for the root
+	Name          string               `json:"name"` // Module path, package name or Bazel label
+	Kind          string               `json:"kind"` // "go", "npm", "cargo" or "bazel"
+	Files         int                  `json:"files"`
+	Bytes         int                  `json:"bytes"`
+	Languages     map[string]int       `json:"languages"`
+	Dependencies  int                  `json:"dependencies"`   // Direct dependencies declared in its manifests
+	Commits       int                  `json:"commits"`        // Commits touching the package, up to the history limit
+	RecentCommits int                  `json:"recent_commits"` // Of those, commits in the last 90 days
+	Contributors  []PackageContributor `json:"contributors"`   // Most commits first
+	Testing       TestReport           `json:"testing"`
+}
+
+// PackageContributor is someone who committed to a package
+type PackageContributor struct {
+	Login   string `json:"login"`
+	Commits int    `json:"commits"`
+}
+
+// Submodule is a git submodule: a path pinned to a commit of another repository
+type Submodule struct {
+	Path      string `json:"path"`
+	URL       string `json:"url"` // As written in .gitmodules
+	SHA       string `json:"sha"` // Pinned commit
+	Host      string `json:"host,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	Supported bool   `json:"supported"` // Hosted on GitHub, so it can be expanded
+	Included  bool   `json:"included"`  // Its files were part of the analysis
+}
+
+// SignatureReport is the commit signing section of a report: how many commits are signed
+// and verified, by whom and since when, and which unverified commits sit on protected branches
+type SignatureReport struct {
+	Commits                    int                     `json:"commits"`
+	Signed                     int                     `json:"signed"`
+	Verified                   int                     `json:"verified"`
+	SignedRate                 float64                 `json:"signed_rate"`
+	VerifiedRate               float64                 `json:"verified_rate"`
+	SignatureTypes             map[string]int          `json:"signature_types"` // "gpg", "ssh", "x509"
+	Reasons                    map[string]int          `json:"reasons"`         // Why commits weren't verified, e.g. "unsigned", "unknown_key"
+	Contributors               []ContributorSignatures `json:"contributors"`    // Most commits first
+	Timeline                   []SignatureMonth        `json:"timeline"`        // Oldest month first
+	ProtectedBranches          []string                `json:"protected_branches"`
+	ProtectedBranchesChecked   bool                    `json:"protected_branches_checked"` // False for snapshots: branch protection and heads are only known for today
+	UnverifiedOnProtected      []UnverifiedCommit      `json:"unverified_on_protected"`    // Newest first, capped
+	UnverifiedOnProtectedCount int                     `json:"unverified_on_protected_count"`
+}
+
+// ContributorSignatures is one contributor's signing habits
+type ContributorSignatures struct {
+	Author       string  `json:"author"`
+	Commits      int     `json:"commits"`
+	Signed       int     `json:"signed"`
+	Verified     int     `json:"verified"`
+	VerifiedRate float64 `json:"verified_rate"`
+}
+
+// SignatureMonth is the signing share of one calendar month (UTC)
+type SignatureMonth struct {
+	Month    string `json:"month"` // "2024-03"
+	Commits  int    `json:"commits"`
+	Signed   int    `json:"signed"`
+	Verified int    `json:"verified"`
+}
+
+// UnverifiedCommit is a commit without a verified signature on a protected branch
+type UnverifiedCommit struct {
+	SHA    string    `json:"sha"`
+	Author string    `json:"author"`
+	Branch string    `json:"branch"`
+	Reason string    `json:"reason"`
+	Date   time.Time `json:"date"`
+}
+
+// HealthReport is the community health checklist of a report, scored out of 100
+type HealthReport struct {
+	Score   int           `json:"score"`
+	Checks  []HealthCheck `json:"checks"`
+	Missing []string      `json:"missing"` // IDs of the failed checks
+}
+
+// HealthCheck is one item of the community health checklist
+type HealthCheck struct {
+	ID     string `json:"id"` // e.g. "readme", "security_policy", "issue_templates"
+	Label  string `json:"label"`
+	Passed bool   `json:"passed"`
+	Path   string `json:"path,omitempty"` // The file or folder that satisfied it
+	Weight int    `json:"weight"`         // Points it is worth
+	Why    string `json:"why"`            // Why it matters, shown when it is missing
+}
+
+// ReportSnapshot is one stored analysis of a repository, as listed in its history
+type ReportSnapshot struct {
+	ID           uint       `json:"id"`
+	GeneratedAt  time.Time  `json:"generated_at"`
+	CommitSHA    string     `json:"commit_sha"`
+	Snapshot     bool       `json:"snapshot"` // Built from a past ref or as-of date rather than the head
+	SnapshotRef  string     `json:"snapshot_ref,omitempty"`
+	SnapshotDate *time.Time `json:"snapshot_date,omitempty"`
+	Stars        int        `json:"stars"`
+	Forks        int        `json:"forks"`
+	OpenIssues   int        `json:"open_issues"`
+	HealthScore  int        `json:"health_score"`
+}
+
+// ReportDiff is how a repository changed between two stored analyses
+type ReportDiff struct {
+	From         ReportSnapshot       `json:"from"`
+	To           ReportSnapshot       `json:"to"`
+	Stars        CountDelta           `json:"stars"`
+	Forks        CountDelta           `json:"forks"`
+	OpenIssues   CountDelta           `json:"open_issues"`
+	Contributors ContributorDelta     `json:"contributors"`
+	Languages    []LanguageShareDelta `json:"languages"` // Biggest share change first
+	Velocity     VelocityDelta        `json:"velocity"`
+	Scores       []ScoreDelta         `json:"scores"`
+}
+
+// CountDelta is a number before and after
+type CountDelta struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Delta int `json:"delta"`
+}
+
+// ContributorDelta is who joined and who dropped out of the contributor list
+type ContributorDelta struct {
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Delta   int      `json:"delta"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// LanguageShareDelta is how one language's share of the code moved
+type LanguageShareDelta struct {
+	Language   string  `json:"language"`
+	FromBytes  int     `json:"from_bytes"`
+	ToBytes    int     `json:"to_bytes"`
+	FromShare  float64 `json:"from_share"` // Percent of all code bytes
+	ToShare    float64 `json:"to_share"`
+	ShareDelta float64 `json:"share_delta"` // Percentage points
+}
+
+// VelocityDelta compares commits per week over the weeks before each analysis
+type VelocityDelta struct {
+	WindowWeeks int     `json:"window_weeks"`
+	From        float64 `json:"from"`
+	To          float64 `json:"to"`
+	Delta       float64 `json:"delta"`
+}
+
+// ScoreDelta is one score or count of the report sections, before and after
+type ScoreDelta struct {
+	Name  string  `json:"name"` // e.g. "health_score", "test_ratio", "high_severity_vulnerabilities"
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Delta float64 `json:"delta"`
+}
+
+// ChatSession is a conversation about a set of files of a repository, kept on the server
+// so it survives reloads and can be shared by its ID
+type ChatSession struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	Repo      string     `json:"repo"` // owner/name
+	Files     []string   `json:"files" gorm:"serializer:json"`
+	Ref       string     `json:"ref"` // Commit the files are read at, fixed when the session starts
+	Messages  []ChatTurn `json:"messages" gorm:"serializer:json"`
+	Version   int        `json:"-"` // Bumped on every append, so concurrent posts can't drop a turn
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ChatTurn is one message of a chat session
+type ChatTurn struct {
+	Role    string    `json:"role"` // "user" or "assistant"
+	Content string    `json:"content"`
+	Voice   bool      `json:"voice,omitempty"` // Sent through voice chat
+	At      time.Time `json:"at"`
+}
+
+// ChatSessionSummary is a chat session as listed to admins: metadata only, no transcript
+type ChatSessionSummary struct {
+	ID           string    `json:"id"`
+	Repo         string    `json:"repo"`
+	Files        []string  `json:"files"`
+	Ref          string    `json:"ref"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ErasureRecord is the log entry of a contributor erasure. It keeps keyed hashes of the
+// erased identities, never the identities themselves, so later analyses can leave them out.
+type ErasureRecord struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	IdentityHashes []string  `json:"identity_hashes" gorm:"serializer:json"` // Logins, names and emails, lowercased
+	Reason         string    `json:"reason"`
+	Reports        int       `json:"reports"`       // Stored analyses the contributor was removed from
+	Commits        int       `json:"commits"`       // Rows of the commits table that were anonymized
+	ChatSessions   int       `json:"chat_sessions"` // Transcripts that mentioned the contributor
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -2,6 +2,7 @@ package voice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,7 +32,7 @@ type TTSRequest struct {
 
 // TextToSpeech converts text to speech using ElevenLabs
 // Returns audio data as bytes
-func (e *ElevenLabsClient) TextToSpeech(text string) ([]byte, error) {
+func (e *ElevenLabsClient) TextToSpeech(ctx context.Context, text string) ([]byte, error) {
 	// Use a natural, conversational voice
 	voiceID := "21m00Tcm4TlvDq8ikWAM" // Rachel voice - natural and clear
 
@@ -51,7 +52,7 @@ func (e *ElevenLabsClient) TextToSpeech(text string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -58,6 +58,8 @@ func (h *Handler) HandleVoiceConversation(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	ctx := r.Context()
+
 	// Generate text response using Gemini
 	chatReq := &ai.ChatRequest{
 		Owner:   req.Owner,
@@ -68,7 +70,7 @@ func (h *Handler) HandleVoiceConversation(w http.ResponseWriter, r *http.Request
 	}
 
 	// Use voice-optimized response generation
-	chatResp, err := h.geminiClient.GenerateVoiceResponse(h.githubClient, chatReq)
+	chatResp, err := h.geminiClient.GenerateVoiceResponse(ctx, h.githubClient, chatReq)
 	if err != nil {
 		log.Printf("Failed to generate AI response: %v", err)
 		json.NewEncoder(w).Encode(VoiceResponse{
@@ -78,7 +80,7 @@ func (h *Handler) HandleVoiceConversation(w http.ResponseWriter, r *http.Request
 	}
 
 	// Convert text to speech using ElevenLabs
-	audioData, err := h.elevenlabsClient.TextToSpeech(chatResp.Response)
+	audioData, err := h.elevenlabsClient.TextToSpeech(ctx, chatResp.Response)
 	if err != nil {
 		log.Printf("Failed to convert text to speech: %v", err)
 		// Return text response even if TTS fails
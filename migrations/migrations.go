@@ -0,0 +1,8 @@
+// Package migrations holds the numbered SQL migrations, one directory per database dialect.
+// Files are named NNNN_description.up.sql and NNNN_description.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var Files embed.FS
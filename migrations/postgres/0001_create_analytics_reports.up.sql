@@ -0,0 +1,21 @@
+-- Baseline: the first release's analytics_reports table, with the JSON columns as jsonb.
+-- A Postgres database from before versioned migrations may already have the columns
+-- added since then; 0007 adds the ones it is missing.
+CREATE TABLE IF NOT EXISTS "analytics_reports" (
+    "id" bigserial PRIMARY KEY,
+    "name" text,
+    "full_name" text,
+    "description" text,
+    "html_url" text,
+    "language" text,
+    "languages" jsonb,
+    "stars" bigint,
+    "forks" bigint,
+    "open_issues" bigint,
+    "created_at" timestamptz,
+    "contributors" jsonb,
+    "file_types" jsonb,
+    "commit_timeline" jsonb,
+    "generated_at" timestamptz
+);
+CREATE INDEX IF NOT EXISTS "idx_analytics_reports_full_name" ON "analytics_reports" ("full_name");
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "idx_analytics_reports_full_name_generated_at";
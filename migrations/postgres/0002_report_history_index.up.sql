@@ -0,0 +1,2 @@
+-- Every analysis is kept; history listings and "latest report" lookups sort by generated_at
+CREATE INDEX IF NOT EXISTS "idx_analytics_reports_full_name_generated_at" ON "analytics_reports" ("full_name", "generated_at");
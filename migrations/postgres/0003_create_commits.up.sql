@@ -0,0 +1,25 @@
+-- One row per commit of an analyzed repository; report timelines and contributors are built from it
+CREATE TABLE IF NOT EXISTS "commits" (
+    "id" bigserial PRIMARY KEY,
+    "repo" text NOT NULL,
+    "sha" text NOT NULL,
+    "author_login" text,
+    "author_name" text,
+    "author_email" text,
+    "author_avatar_url" text,
+    "authored_at" timestamptz,
+    "authored_offset" bigint,
+    "committed_at" timestamptz,
+    "committed_offset" bigint,
+    "offset_known" boolean,
+    "message" text,
+    "additions" bigint,
+    "deletions" bigint,
+    "stats_known" boolean,
+    "signature" text,
+    "verified" boolean,
+    "verification_reason" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_commits_repo_sha" ON "commits" ("repo", "sha");
+CREATE INDEX IF NOT EXISTS "idx_commits_repo_authored_at" ON "commits" ("repo", "authored_at");
+CREATE INDEX IF NOT EXISTS "idx_commits_repo_author_login" ON "commits" ("repo", "author_login");
@@ -0,0 +1,13 @@
+-- Smart Summaries, reused until the repository's tree, the model or the prompt version changes
+CREATE TABLE IF NOT EXISTS "stored_summaries" (
+    "id" bigserial PRIMARY KEY,
+    "repo" text NOT NULL,
+    "commit_sha" text,
+    "tree_sha" text NOT NULL,
+    "include_submodules" boolean DEFAULT false,
+    "model" text,
+    "prompt_version" text,
+    "summary" jsonb,
+    "generated_at" timestamptz
+);
+CREATE INDEX IF NOT EXISTS "idx_stored_summaries_repo_tree_sha" ON "stored_summaries" ("repo", "tree_sha");
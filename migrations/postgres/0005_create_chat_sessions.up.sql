@@ -0,0 +1,12 @@
+-- Server-side chat sessions; the transcript is a jsonb array of turns
+CREATE TABLE IF NOT EXISTS "chat_sessions" (
+    "id" text PRIMARY KEY,
+    "repo" text NOT NULL,
+    "files" jsonb,
+    "ref" text,
+    "messages" jsonb,
+    "version" bigint DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz
+);
+CREATE INDEX IF NOT EXISTS "idx_chat_sessions_repo_updated_at" ON "chat_sessions" ("repo", "updated_at");
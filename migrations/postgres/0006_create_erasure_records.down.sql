@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_analytics_reports_generated_at";
+DROP TABLE IF EXISTS "erasure_records";
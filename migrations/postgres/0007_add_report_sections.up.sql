@@ -0,0 +1,22 @@
+-- Report sections and snapshot fields added since the baseline. A database AutoMigrate
+-- already brought up to date keeps its columns; IF NOT EXISTS skips them
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "default_branch" text;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "license" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "topics" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "commits" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "working_hours" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "dependencies" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "licenses" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "secrets" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "vulnerabilities" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "ci" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "testing" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "workspace" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "submodules" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "signatures" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "health" jsonb;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "commit_sha" text;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "snapshot" boolean DEFAULT false;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "snapshot_ref" text;
+ALTER TABLE "analytics_reports" ADD COLUMN IF NOT EXISTS "snapshot_date" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_analytics_reports_snapshot" ON "analytics_reports" ("snapshot");
@@ -0,0 +1,21 @@
+-- Baseline: the analytics_reports table exactly as the first release's AutoMigrate created
+-- it, so databases from before versioned migrations adopt this version without changes.
+-- Columns added since then come in 0007.
+CREATE TABLE IF NOT EXISTS `analytics_reports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text,
+    `full_name` text,
+    `description` text,
+    `html_url` text,
+    `language` text,
+    `languages` text,
+    `stars` integer,
+    `forks` integer,
+    `open_issues` integer,
+    `created_at` datetime,
+    `contributors` text,
+    `file_types` text,
+    `commit_timeline` text,
+    `generated_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_analytics_reports_full_name` ON `analytics_reports`(`full_name`);
@@ -0,0 +1,25 @@
+-- One row per commit of an analyzed repository; report timelines and contributors are built from it
+CREATE TABLE IF NOT EXISTS `commits` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `repo` text NOT NULL,
+    `sha` text NOT NULL,
+    `author_login` text,
+    `author_name` text,
+    `author_email` text,
+    `author_avatar_url` text,
+    `authored_at` datetime,
+    `authored_offset` integer,
+    `committed_at` datetime,
+    `committed_offset` integer,
+    `offset_known` numeric,
+    `message` text,
+    `additions` integer,
+    `deletions` integer,
+    `stats_known` numeric,
+    `signature` text,
+    `verified` numeric,
+    `verification_reason` text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_commits_repo_sha` ON `commits`(`repo`, `sha`);
+CREATE INDEX IF NOT EXISTS `idx_commits_repo_authored_at` ON `commits`(`repo`, `authored_at`);
+CREATE INDEX IF NOT EXISTS `idx_commits_repo_author_login` ON `commits`(`repo`, `author_login`);
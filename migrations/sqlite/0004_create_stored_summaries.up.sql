@@ -0,0 +1,13 @@
+-- Smart Summaries, reused until the repository's tree, the model or the prompt version changes
+CREATE TABLE IF NOT EXISTS `stored_summaries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `repo` text NOT NULL,
+    `commit_sha` text,
+    `tree_sha` text NOT NULL,
+    `include_submodules` numeric DEFAULT false,
+    `model` text,
+    `prompt_version` text,
+    `summary` text,
+    `generated_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_stored_summaries_repo_tree_sha` ON `stored_summaries`(`repo`, `tree_sha`);
@@ -0,0 +1,12 @@
+-- Server-side chat sessions; the transcript is a JSON array of turns
+CREATE TABLE IF NOT EXISTS `chat_sessions` (
+    `id` text PRIMARY KEY,
+    `repo` text NOT NULL,
+    `files` text,
+    `ref` text,
+    `messages` text,
+    `version` integer DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_chat_sessions_repo_updated_at` ON `chat_sessions`(`repo`, `updated_at`);
@@ -0,0 +1,12 @@
+-- Log of contributor erasures; only hashes of the erased identities are kept
+CREATE TABLE IF NOT EXISTS `erasure_records` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `identity_hashes` text,
+    `reason` text,
+    `reports` integer,
+    `commits` integer,
+    `chat_sessions` integer,
+    `created_at` datetime
+);
+-- The retention purge deletes reports by age across all repositories
+CREATE INDEX IF NOT EXISTS `idx_analytics_reports_generated_at` ON `analytics_reports`(`generated_at`);
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS `idx_analytics_reports_snapshot`;
+ALTER TABLE `analytics_reports` DROP COLUMN `snapshot_date`;
+ALTER TABLE `analytics_reports` DROP COLUMN `snapshot_ref`;
+ALTER TABLE `analytics_reports` DROP COLUMN `snapshot`;
+ALTER TABLE `analytics_reports` DROP COLUMN `commit_sha`;
+ALTER TABLE `analytics_reports` DROP COLUMN `health`;
+ALTER TABLE `analytics_reports` DROP COLUMN `signatures`;
+ALTER TABLE `analytics_reports` DROP COLUMN `submodules`;
+ALTER TABLE `analytics_reports` DROP COLUMN `workspace`;
+ALTER TABLE `analytics_reports` DROP COLUMN `testing`;
+ALTER TABLE `analytics_reports` DROP COLUMN `ci`;
+ALTER TABLE `analytics_reports` DROP COLUMN `vulnerabilities`;
+ALTER TABLE `analytics_reports` DROP COLUMN `secrets`;
+ALTER TABLE `analytics_reports` DROP COLUMN `licenses`;
+ALTER TABLE `analytics_reports` DROP COLUMN `dependencies`;
+ALTER TABLE `analytics_reports` DROP COLUMN `working_hours`;
+ALTER TABLE `analytics_reports` DROP COLUMN `commits`;
+ALTER TABLE `analytics_reports` DROP COLUMN `topics`;
+ALTER TABLE `analytics_reports` DROP COLUMN `license`;
+ALTER TABLE `analytics_reports` DROP COLUMN `default_branch`;
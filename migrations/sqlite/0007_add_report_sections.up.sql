@@ -0,0 +1,22 @@
+-- Report sections and snapshot fields added since the baseline. A database AutoMigrate
+-- already brought up to date keeps its columns; IF NOT EXISTS skips them
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `default_branch` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `license` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `topics` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `commits` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `working_hours` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `dependencies` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `licenses` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `secrets` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `vulnerabilities` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `ci` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `testing` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `workspace` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `submodules` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `signatures` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `health` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `commit_sha` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `snapshot` numeric DEFAULT false;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `snapshot_ref` text;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `snapshot_date` datetime;
+CREATE INDEX IF NOT EXISTS `idx_analytics_reports_snapshot` ON `analytics_reports`(`snapshot`);
@@ -0,0 +1,2 @@
+ALTER TABLE `analytics_reports` DROP COLUMN `include_submodules`;
+ALTER TABLE `analytics_reports` DROP COLUMN `scan_history`;
@@ -0,0 +1,3 @@
+-- The options a report was built with, so a cached report only answers requests it covers
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `scan_history` numeric DEFAULT false;
+ALTER TABLE `analytics_reports` ADD COLUMN IF NOT EXISTS `include_submodules` numeric DEFAULT false;
@@ -34,7 +34,9 @@ function FileItem({
 }: FileItemProps) {
   const [isExpanded, setIsExpanded] = useState(depth === 0);
   const isSelected = selectedFiles.includes(node.path);
-  const isFolder = node.type === "folder";
+  // Submodules expand like folders when their files were included
+  const isFolder =
+    node.type === "folder" || (node.type === "submodule" && !!node.children?.length);
 
   const handleCheckboxClick = (e: React.MouseEvent) => {
     e.stopPropagation();
@@ -46,7 +48,7 @@ function FileItem({
   const handleRowClick = () => {
     if (isFolder) {
       setIsExpanded(!isExpanded);
-    } else {
+    } else if (node.type === "file") {
       onToggle(node.path);
     }
   };
@@ -132,6 +134,13 @@ function FileItem({
         >
           {node.name}
         </span>
+
+        {/* Pinned commit for submodules */}
+        {node.type === "submodule" && node.sha && (
+          <span className="text-xs font-mono text-zinc-400 dark:text-zinc-500 truncate" title={node.url}>
+            @{node.sha.slice(0, 7)}
+          </span>
+        )}
       </motion.div>
 
       {/* Children (for folders) */}
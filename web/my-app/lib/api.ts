@@ -13,13 +13,29 @@ export interface SmartSummary {
   code_quality_score: number;
   complexity: "Low" | "Medium" | "High";
   latex_code: string;
+  skipped_files?: SkippedFile[];
+  critical_files?: string[];
+  model?: string;
+  prompt_version?: string;
+  commit_sha?: string;
+  tree_sha?: string;
+  generated_at?: string;
+  cached?: boolean; // served from the database instead of generated now
+}
+
+// A requested file the backend could not fetch, and why
+export interface SkippedFile {
+  path: string;
+  reason: "not_found" | "too_large" | "rate_limited" | "forbidden" | "canceled" | "request_failed";
 }
 
 // FileNode type for file tree structure
 export interface FileNode {
   name: string;
   path: string;
-  type: "file" | "folder";
+  type: "file" | "folder" | "submodule";
+  url?: string; // submodules only
+  sha?: string; // submodules only: the pinned commit
   children?: FileNode[];
 }
 
@@ -79,11 +95,12 @@ export async function getReport(
 // Generate AI-powered smart summary
 export async function generateSmartSummary(
   owner: string,
-  repo: string
+  repo: string,
+  refresh = false
 ): Promise<SmartSummary> {
   return fetchAPI<SmartSummary>("/api/smart-summary", {
     method: "POST",
-    body: JSON.stringify({ owner, repo }),
+    body: JSON.stringify({ owner, repo, refresh }),
   });
 }
 
@@ -108,12 +125,14 @@ export interface ChatMessage {
 // Chat response type
 export interface ChatResponse {
   response: string;
+  skipped?: SkippedFile[];
   error?: string;
 }
 
 // Voice chat response type
 export interface VoiceChatResponse {
   response: string;
+  skipped?: SkippedFile[];
   audio?: string; // base64 encoded audio
   audio_error?: string;
 }
@@ -146,6 +165,52 @@ export async function voiceChatWithRepo(
   });
 }
 
+// A chat session stored on the server, so the conversation survives reloads
+export interface ChatSession {
+  id: string;
+  repo: string;
+  files: string[];
+  ref: string; // commit SHA the files are read at
+  messages: Array<ChatMessage & { voice?: boolean; at: string }>;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ChatSessionReply extends VoiceChatResponse {
+  session_id: string;
+  message_count: number;
+}
+
+// Start a stored chat session about some files
+export async function createChatSession(
+  owner: string,
+  repo: string,
+  files: string[],
+  ref?: string
+): Promise<ChatSession> {
+  return fetchAPI<ChatSession>("/api/chat/sessions", {
+    method: "POST",
+    body: JSON.stringify({ owner, repo, files, ref }),
+  });
+}
+
+// Load a chat session with its transcript
+export async function getChatSession(id: string): Promise<ChatSession> {
+  return fetchAPI<ChatSession>(`/api/chat/sessions/${encodeURIComponent(id)}`);
+}
+
+// Send a message in a chat session; the server keeps the history
+export async function sendChatSessionMessage(
+  id: string,
+  message: string,
+  voice = false
+): Promise<ChatSessionReply> {
+  return fetchAPI<ChatSessionReply>(`/api/chat/sessions/${encodeURIComponent(id)}/messages`, {
+    method: "POST",
+    body: JSON.stringify({ message, voice }),
+  });
+}
+
 // Start voice call with greeting
 export async function startVoiceCall(
   owner: string,
@@ -166,6 +231,9 @@ export const api = {
   chatWithRepo,
   voiceChatWithRepo,
   startVoiceCall,
+  createChatSession,
+  getChatSession,
+  sendChatSessionMessage,
 };
 
 export default api;